@@ -0,0 +1,75 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/yourusername/cloudshaver/internal/registry"
+	"github.com/yourusername/cloudshaver/internal/types"
+)
+
+// bladeInfo is the listed description of a registered blade
+type bladeInfo struct {
+	Name        string   `json:"name"`
+	Provider    string   `json:"provider"`
+	Category    string   `json:"category"`
+	Services    []string `json:"services"`
+	Description string   `json:"description"`
+}
+
+func runListBlades(args []string) int {
+	fs := flag.NewFlagSet("list-blades", flag.ContinueOnError)
+	fs.SetOutput(os.Stderr)
+
+	provider := fs.String("provider", "", "only list blades for this cloud provider")
+	output := fs.String("output", "text", "output format (text, json)")
+
+	if err := fs.Parse(args); err != nil {
+		if errors.Is(err, flag.ErrHelp) {
+			return exitOK
+		}
+		return exitUsage
+	}
+
+	var blades []bladeInfo
+	for _, registration := range registry.List() {
+		if *provider != "" && registration.Provider != types.CloudProvider(strings.ToLower(*provider)) {
+			continue
+		}
+		blades = append(blades, bladeInfo{
+			Name:        registration.Name,
+			Provider:    string(registration.Provider),
+			Category:    string(registration.Category),
+			Services:    registration.Services,
+			Description: registration.Description,
+		})
+	}
+
+	switch strings.ToLower(*output) {
+	case "json":
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "  ")
+		if err := enc.Encode(blades); err != nil {
+			fmt.Fprintf(os.Stderr, "list-blades: %v\n", err)
+			return exitBladeFailed
+		}
+	case "text":
+		tw := tabwriter.NewWriter(os.Stdout, 0, 4, 2, ' ', 0)
+		fmt.Fprintln(tw, "NAME\tPROVIDER\tCATEGORY\tSERVICES\tDESCRIPTION")
+		for _, blade := range blades {
+			fmt.Fprintf(tw, "%s\t%s\t%s\t%s\t%s\n", blade.Name, blade.Provider, blade.Category,
+				strings.Join(blade.Services, ","), blade.Description)
+		}
+		tw.Flush()
+	default:
+		fmt.Fprintf(os.Stderr, "list-blades: unsupported output format: %s\n", *output)
+		return exitUsage
+	}
+
+	return exitOK
+}
@@ -0,0 +1,53 @@
+package main
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/sirupsen/logrus"
+)
+
+// Exit codes returned by the cloudshaver binary
+const (
+	exitOK          = 0
+	exitBladeFailed = 1
+	exitUsage       = 2
+)
+
+const usage = `Usage: cloudshaver <command> [flags]
+
+Commands:
+  scan          Run cost-saving blades and print their results
+  list-blades   List the available blades
+  pricing       Export price lists or generate the static pricing data
+
+Run 'cloudshaver <command> -h' for details on a command.
+`
+
+func main() {
+	os.Exit(run(os.Args[1:]))
+}
+
+func run(args []string) int {
+	logrus.SetOutput(os.Stderr)
+
+	if len(args) == 0 {
+		fmt.Fprint(os.Stderr, usage)
+		return exitUsage
+	}
+
+	switch args[0] {
+	case "scan":
+		return runScan(args[1:])
+	case "list-blades":
+		return runListBlades(args[1:])
+	case "pricing":
+		return runPricing(args[1:])
+	case "-h", "-help", "--help", "help":
+		fmt.Fprint(os.Stdout, usage)
+		return exitOK
+	default:
+		fmt.Fprintf(os.Stderr, "unknown command: %s\n\n%s", args[0], usage)
+		return exitUsage
+	}
+}
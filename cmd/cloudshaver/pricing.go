@@ -0,0 +1,228 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"os/signal"
+	"strings"
+	"syscall"
+
+	"github.com/sirupsen/logrus"
+	awspricing "github.com/yourusername/cloudshaver/internal/pricing/aws"
+	pricingclient "github.com/yourusername/cloudshaver/internal/pricing/client"
+)
+
+const pricingUsage = `Usage: cloudshaver pricing <command> [flags]
+
+Commands:
+  export     Download price lists into a snapshot for offline scans
+  generate   Distill price lists into the static ec2_pricing.json format
+
+Run 'cloudshaver pricing <command> -h' for details on a command.
+`
+
+func runPricing(args []string) int {
+	if len(args) == 0 {
+		fmt.Fprint(os.Stderr, pricingUsage)
+		return exitUsage
+	}
+
+	switch args[0] {
+	case "export":
+		return runPricingExport(args[1:])
+	case "generate":
+		return runPricingGenerate(args[1:])
+	case "-h", "-help", "--help", "help":
+		fmt.Fprint(os.Stdout, pricingUsage)
+		return exitOK
+	default:
+		fmt.Fprintf(os.Stderr, "unknown pricing command: %s\n\n%s", args[0], pricingUsage)
+		return exitUsage
+	}
+}
+
+func runPricingExport(args []string) int {
+	fs := flag.NewFlagSet("pricing export", flag.ContinueOnError)
+	fs.SetOutput(os.Stderr)
+
+	regions := fs.String("regions", defaultRegion(), "comma-separated list of regions to export, or \"all\" for every region with prices")
+	dir := fs.String("dir", "", "directory the snapshot is written to (required)")
+	pricingCache := addPricingCacheFlags(fs)
+
+	if err := fs.Parse(args); err != nil {
+		if errors.Is(err, flag.ErrHelp) {
+			return exitOK
+		}
+		return exitUsage
+	}
+	cache, err := pricingCache()
+	if err == nil && *dir == "" {
+		err = fmt.Errorf("-dir is required")
+	}
+	if err == nil && fs.NArg() > 0 {
+		err = fmt.Errorf("unexpected arguments: %s", strings.Join(fs.Args(), " "))
+	}
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "pricing export: %v\n", err)
+		return exitUsage
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	source, err := awspricing.NewSource(awspricing.SourceOptions{Cache: cache})
+	if err != nil {
+		logrus.WithError(err).Error("Failed to open pricing data")
+		return exitBladeFailed
+	}
+
+	exportRegions := splitList(*regions)
+	if len(exportRegions) == 1 && strings.EqualFold(exportRegions[0], allRegions) {
+		exportRegions, err = source.ServiceRegions(ctx, awspricing.EC2Service)
+		if err != nil {
+			logrus.WithError(err).Error("Failed to list pricing regions")
+			return exitBladeFailed
+		}
+	}
+
+	for _, region := range exportRegions {
+		logrus.WithField("region", region).Info("Exporting pricing data")
+		if err := awspricing.ExportSnapshot(ctx, source, *dir, []string{region}); err != nil {
+			logrus.WithError(err).Error("Pricing export failed")
+			return exitBladeFailed
+		}
+	}
+
+	return exitOK
+}
+
+func runPricingGenerate(args []string) int {
+	fs := flag.NewFlagSet("pricing generate", flag.ContinueOnError)
+	fs.SetOutput(os.Stderr)
+
+	regions := fs.String("regions", allRegions, "comma-separated list of regions to include, or \"all\" for every region with prices")
+	output := fs.String("output", "", "file the pricing data is written to (default: stdout, unless -diff is set)")
+	previousPath := fs.String("previous", "", "previous pricing data to diff against and copy savings opportunities from (default: -output if it exists, else the bundled data)")
+	diff := fs.Bool("diff", false, "print the price changes against the previous pricing data")
+	pricingCache := addPricingCacheFlags(fs)
+
+	if err := fs.Parse(args); err != nil {
+		if errors.Is(err, flag.ErrHelp) {
+			return exitOK
+		}
+		return exitUsage
+	}
+	cache, err := pricingCache()
+	if err == nil && fs.NArg() > 0 {
+		err = fmt.Errorf("unexpected arguments: %s", strings.Join(fs.Args(), " "))
+	}
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "pricing generate: %v\n", err)
+		return exitUsage
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	if *previousPath == "" && *output != "" {
+		if _, err := os.Stat(*output); err == nil {
+			*previousPath = *output
+		}
+	}
+	awspricing.SetPricingPath(*previousPath)
+	previous, err := awspricing.LoadPricing()
+	if err != nil {
+		logrus.WithError(err).Error("Failed to load previous pricing data")
+		return exitBladeFailed
+	}
+
+	source, err := awspricing.NewSource(awspricing.SourceOptions{Cache: cache})
+	if err != nil {
+		logrus.WithError(err).Error("Failed to open pricing data")
+		return exitBladeFailed
+	}
+
+	generateRegions := splitList(*regions)
+	if len(generateRegions) == 1 && strings.EqualFold(generateRegions[0], allRegions) {
+		generateRegions, err = source.ServiceRegions(ctx, awspricing.EC2Service)
+		if err != nil {
+			logrus.WithError(err).Error("Failed to list pricing regions")
+			return exitBladeFailed
+		}
+	}
+
+	pricing, err := awspricing.GenerateStaticPricing(ctx, source, generateRegions, previous)
+	if err != nil {
+		logrus.WithError(err).Error("Pricing generation failed")
+		return exitBladeFailed
+	}
+
+	if *diff {
+		writePriceChanges(os.Stdout, awspricing.DiffPricing(previous, pricing))
+		if *output == "" {
+			return exitOK
+		}
+	}
+
+	data, err := json.MarshalIndent(pricing, "", "    ")
+	if err != nil {
+		logrus.WithError(err).Error("Failed to encode pricing data")
+		return exitBladeFailed
+	}
+	data = append(data, '\n')
+
+	if *output == "" {
+		_, err = os.Stdout.Write(data)
+	} else {
+		err = os.WriteFile(*output, data, 0o644)
+	}
+	if err != nil {
+		logrus.WithError(err).Error("Failed to write pricing data")
+		return exitBladeFailed
+	}
+	return exitOK
+}
+
+// writePriceChanges prints one line per changed price
+func writePriceChanges(w io.Writer, changes []awspricing.PriceChange) {
+	if len(changes) == 0 {
+		fmt.Fprintln(w, "No price changes")
+		return
+	}
+
+	for _, change := range changes {
+		name := fmt.Sprintf("%s %s %s %s", change.Region, change.Resource, change.Name, change.Field)
+		switch {
+		case change.Old == 0:
+			fmt.Fprintf(w, "+ %s: %g\n", name, change.New)
+		case change.New == 0:
+			fmt.Fprintf(w, "- %s: %g\n", name, change.Old)
+		default:
+			fmt.Fprintf(w, "~ %s: %g -> %g (%+.1f%%)\n", name, change.Old, change.New, (change.New-change.Old)/change.Old*100)
+		}
+	}
+}
+
+// addPricingCacheFlags defines the flags configuring the pricing cache on fs
+// and returns a function reading them once fs is parsed
+func addPricingCacheFlags(fs *flag.FlagSet) func() (pricingclient.CacheOptions, error) {
+	dir := fs.String("pricing-cache-dir", "", "directory of the pricing cache (default: user cache directory)")
+	maxMB := fs.Int64("pricing-cache-max-mb", 0, "size of the pricing cache in MB before old price lists are evicted (default: 8192)")
+	ttl := fs.Duration("pricing-cache-ttl", 0, "how long pricing indexes are trusted before they are revalidated, e.g. 6h (default: 24h)")
+
+	return func() (pricingclient.CacheOptions, error) {
+		if *maxMB < 0 || *ttl < 0 {
+			return pricingclient.CacheOptions{}, fmt.Errorf("pricing cache size and TTL must not be negative")
+		}
+		return pricingclient.CacheOptions{
+			Dir:     *dir,
+			MaxSize: *maxMB << 20,
+			TTL:     *ttl,
+		}, nil
+	}
+}
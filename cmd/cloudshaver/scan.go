@@ -0,0 +1,332 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"os/signal"
+	"sort"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	awsutil "github.com/yourusername/cloudshaver/internal/aws"
+	"github.com/yourusername/cloudshaver/internal/factory"
+	awspricing "github.com/yourusername/cloudshaver/internal/pricing/aws"
+	"github.com/yourusername/cloudshaver/internal/scanner"
+	"github.com/yourusername/cloudshaver/internal/types"
+)
+
+// allRegions is the -regions value that scans every enabled region
+const allRegions = "all"
+
+// scanOptions holds the parsed flags of the scan command
+type scanOptions struct {
+	provider    string
+	regions     []string
+	allRegions  bool
+	accounts    []awsutil.Account
+	orgAccounts bool
+	assumeRole  awsutil.AssumeRoleOptions
+	blades      []string
+	exclude     []string
+	output      string
+	concurrency int
+	lookback    time.Duration
+	steadyState time.Duration
+	idle        time.Duration
+	retention   time.Duration
+	pricing     awspricing.SourceOptions
+	pricingData string
+	timeout     time.Duration
+	verbose     bool
+}
+
+func runScan(args []string) int {
+	opts, err := parseScanFlags(args)
+	if err != nil {
+		if errors.Is(err, flag.ErrHelp) {
+			return exitOK
+		}
+		fmt.Fprintf(os.Stderr, "scan: %v\n", err)
+		return exitUsage
+	}
+
+	if opts.verbose {
+		logrus.SetLevel(logrus.DebugLevel)
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	if opts.timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, opts.timeout)
+		defer cancel()
+	}
+
+	if types.CloudProvider(opts.provider) == types.AWS {
+		if err := awsutil.ValidateCredentials(ctx); err != nil {
+			logrus.WithError(err).Error("AWS credential validation failed")
+			return exitBladeFailed
+		}
+	}
+
+	if opts.pricingData != "" {
+		awspricing.SetPricingPath(opts.pricingData)
+		if _, err := awspricing.LoadPricing(); err != nil {
+			logrus.WithError(err).Error("Failed to load pricing data")
+			return exitBladeFailed
+		}
+	}
+
+	pricingSource, err := awspricing.NewSource(opts.pricing)
+	if err != nil {
+		logrus.WithError(err).Error("Failed to open pricing data")
+		return exitBladeFailed
+	}
+
+	report, err := scanner.Run(ctx, factory.BladeConfig{
+		Provider:             types.CloudProvider(opts.provider),
+		Region:               defaultRegion(),
+		Regions:              opts.regions,
+		AllRegions:           opts.allRegions,
+		Accounts:             opts.accounts,
+		OrganizationAccounts: opts.orgAccounts,
+		AssumeRole:           opts.assumeRole,
+		MetricsLookback:      opts.lookback,
+		SteadyStateAge:       opts.steadyState,
+		IdleAge:              opts.idle,
+		SnapshotRetention:    opts.retention,
+		PricingSource:        pricingSource,
+		Include:              opts.blades,
+		Exclude:              opts.exclude,
+	}, scanner.Options{Concurrency: opts.concurrency})
+	if report == nil {
+		logrus.WithError(err).Error("Scan failed")
+		return exitBladeFailed
+	}
+
+	if err := writeScanOutput(os.Stdout, opts.output, report); err != nil {
+		logrus.WithError(err).Error("Failed to write scan output")
+		return exitBladeFailed
+	}
+
+	if err != nil || len(report.Failures) > 0 {
+		if err != nil {
+			logrus.WithError(err).Error("Scan completed with failures")
+		}
+		return exitBladeFailed
+	}
+	return exitOK
+}
+
+func parseScanFlags(args []string) (*scanOptions, error) {
+	fs := flag.NewFlagSet("scan", flag.ContinueOnError)
+	fs.SetOutput(os.Stderr)
+
+	provider := fs.String("provider", string(types.AWS), "cloud provider to scan (aws, azure, gcp)")
+	regions := fs.String("regions", defaultRegion(), "comma-separated list of regions to scan, or \"all\" for every enabled region")
+	accounts := fs.String("accounts", "", "comma-separated account IDs to scan, optionally as id=alias")
+	orgAccounts := fs.Bool("org-accounts", false, "scan every active account of the AWS Organization")
+	assumeRole := fs.String("assume-role", "", "name of the role to assume in each scanned account")
+	externalID := fs.String("external-id", "", "external ID passed when assuming roles")
+	stsEndpoint := fs.String("sts-endpoint", "", "override the STS endpoint used to assume roles")
+	blades := fs.String("blades", "", "comma-separated blade names or categories to run (default: all)")
+	exclude := fs.String("exclude", "", "comma-separated blade names or categories to skip")
+	output := fs.String("output", "text", "output format (text, json)")
+	concurrency := fs.Int("concurrency", scanner.DefaultConcurrency, "number of regions scanned in parallel")
+	lookbackDays := fs.Int("lookback-days", 0, "days of CloudWatch metrics examined for utilization analyses (default: blade default)")
+	steadyStateDays := fs.Int("steady-state-days", 0, "days an instance must have been running to count as steady-state usage for commitments (default: 14)")
+	idleDays := fs.Int("idle-days", 0, "days an instance must have been stopped, or a volume unattached, to be reported (default: minimum_days of the pricing data, 7)")
+	retentionDays := fs.Int("snapshot-retention-days", 0, "age in days beyond which EBS snapshots are reported as expired (default: 365)")
+	pricingCache := addPricingCacheFlags(fs)
+	snapshot := fs.String("pricing-snapshot", "", "read prices from a snapshot written by 'cloudshaver pricing export' instead of downloading them")
+	offline := fs.Bool("offline-pricing", false, "read prices from the bundled pricing data instead of downloading them")
+	pricingData := fs.String("pricing-data", "", "file, or directory holding ec2_pricing.json, replacing the bundled pricing data")
+	timeout := fs.Duration("timeout", 0, "abort the scan after this duration, e.g. 30m (default: no timeout)")
+	verbose := fs.Bool("verbose", false, "enable debug logging")
+
+	if err := fs.Parse(args); err != nil {
+		return nil, err
+	}
+	if fs.NArg() > 0 {
+		return nil, fmt.Errorf("unexpected arguments: %s", strings.Join(fs.Args(), " "))
+	}
+
+	opts := &scanOptions{
+		provider:    strings.ToLower(*provider),
+		regions:     splitList(*regions),
+		orgAccounts: *orgAccounts,
+		assumeRole: awsutil.AssumeRoleOptions{
+			RoleName:    *assumeRole,
+			ExternalID:  *externalID,
+			STSEndpoint: *stsEndpoint,
+		},
+		blades:      splitList(*blades),
+		exclude:     splitList(*exclude),
+		output:      strings.ToLower(*output),
+		concurrency: *concurrency,
+		lookback:    time.Duration(*lookbackDays) * 24 * time.Hour,
+		steadyState: time.Duration(*steadyStateDays) * 24 * time.Hour,
+		idle:        time.Duration(*idleDays) * 24 * time.Hour,
+		retention:   time.Duration(*retentionDays) * 24 * time.Hour,
+		timeout:     *timeout,
+		verbose:     *verbose,
+	}
+
+	cache, err := pricingCache()
+	if err != nil {
+		return nil, err
+	}
+	opts.pricingData = *pricingData
+	opts.pricing = awspricing.SourceOptions{
+		Cache:    cache,
+		Snapshot: *snapshot,
+		Offline:  *offline,
+	}
+
+	parsedAccounts, err := awsutil.ParseAccounts(splitList(*accounts))
+	if err != nil {
+		return nil, err
+	}
+	opts.accounts = parsedAccounts
+	if (opts.orgAccounts || len(opts.accounts) > 0) && opts.assumeRole.RoleName == "" {
+		return nil, fmt.Errorf("-assume-role is required with -accounts or -org-accounts")
+	}
+
+	if len(opts.regions) == 1 && strings.EqualFold(opts.regions[0], allRegions) {
+		opts.regions = nil
+		opts.allRegions = true
+	}
+	if len(opts.regions) == 0 && !opts.allRegions {
+		return nil, fmt.Errorf("at least one region is required")
+	}
+	if *lookbackDays < 0 {
+		return nil, fmt.Errorf("lookback-days must not be negative")
+	}
+	if *steadyStateDays < 0 {
+		return nil, fmt.Errorf("steady-state-days must not be negative")
+	}
+	if *idleDays < 0 {
+		return nil, fmt.Errorf("idle-days must not be negative")
+	}
+	if *retentionDays < 0 {
+		return nil, fmt.Errorf("snapshot-retention-days must not be negative")
+	}
+	if opts.concurrency < 1 {
+		return nil, fmt.Errorf("concurrency must be at least 1")
+	}
+	if opts.output != "text" && opts.output != "json" {
+		return nil, fmt.Errorf("unsupported output format: %s", opts.output)
+	}
+
+	return opts, nil
+}
+
+func writeScanOutput(w io.Writer, format string, report *scanner.Report) error {
+	if format == "json" {
+		enc := json.NewEncoder(w)
+		enc.SetIndent("", "  ")
+		return enc.Encode(report)
+	}
+
+	if len(report.Accounts) > 0 {
+		var accounts []string
+		for _, account := range report.Accounts {
+			if account.Alias != "" {
+				accounts = append(accounts, fmt.Sprintf("%s (%s)", account.ID, account.Alias))
+			} else {
+				accounts = append(accounts, account.ID)
+			}
+		}
+		fmt.Fprintf(w, "Scanned accounts: %s\n", strings.Join(accounts, ", "))
+	}
+	fmt.Fprintf(w, "Scanned regions: %s\n\n", strings.Join(report.Regions, ", "))
+
+	var totalSavings float64
+	for _, bladeReport := range report.Results {
+		fmt.Fprintf(w, "== %s\n", bladeReport.Blade)
+		writeBladeResult(w, bladeReport.Result)
+		totalSavings += bladeReport.Result.PotentialSavings
+		fmt.Fprintln(w)
+	}
+
+	if len(report.Failures) > 0 {
+		fmt.Fprintln(w, "== Failures")
+		for _, failure := range report.Failures {
+			var where []string
+			for _, part := range []string{failure.AccountID, failure.Region, failure.Blade} {
+				if part != "" {
+					where = append(where, part)
+				}
+			}
+			fmt.Fprintf(w, "   %s: %s\n", strings.Join(where, " / "), failure.Error)
+		}
+		fmt.Fprintln(w)
+	}
+
+	fmt.Fprintf(w, "Total potential monthly savings: $%.2f\n", totalSavings)
+
+	return nil
+}
+
+func writeBladeResult(w io.Writer, result *types.BladeResult) {
+	fmt.Fprintf(w, "   provider: %s  category: %s  resource type: %s\n",
+		result.CloudProvider, result.Category, result.ResourceType)
+	fmt.Fprintf(w, "   potential monthly savings: $%.2f\n", result.PotentialSavings)
+
+	if len(result.Details) > 0 {
+		keys := make([]string, 0, len(result.Details))
+		for key := range result.Details {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+		for _, key := range keys {
+			fmt.Fprintf(w, "   %s: %s\n", key, result.Details[key])
+		}
+	}
+
+	for _, finding := range result.Findings {
+		fmt.Fprintf(w, "   - [%s] %s %s (%s): %s\n", finding.Kind, finding.ResourceType, finding.ResourceID,
+			findingLocation(finding), finding.Recommendation)
+		fmt.Fprintf(w, "     $%.2f -> $%.2f per month, saves $%.2f (%s confidence)\n",
+			finding.CurrentCost, finding.ProjectedCost, finding.Savings, finding.Confidence)
+	}
+}
+
+func findingLocation(finding types.Finding) string {
+	switch {
+	case finding.AccountAlias != "":
+		return finding.AccountAlias + "/" + finding.Region
+	case finding.AccountID != "":
+		return finding.AccountID + "/" + finding.Region
+	default:
+		return finding.Region
+	}
+}
+
+func defaultRegion() string {
+	if region := os.Getenv("AWS_REGION"); region != "" {
+		return region
+	}
+	if region := os.Getenv("AWS_DEFAULT_REGION"); region != "" {
+		return region
+	}
+	return "us-east-1"
+}
+
+func splitList(value string) []string {
+	var items []string
+	for _, item := range strings.Split(value, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			items = append(items, item)
+		}
+	}
+	return items
+}
@@ -0,0 +1,169 @@
+package aws
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"strings"
+	"sync"
+
+	sdkaws "github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/credentials/stscreds"
+	"github.com/aws/aws-sdk-go-v2/service/iam"
+	"github.com/aws/aws-sdk-go-v2/service/organizations"
+	orgtypes "github.com/aws/aws-sdk-go-v2/service/organizations/types"
+	"github.com/aws/aws-sdk-go-v2/service/sts"
+)
+
+// DefaultRoleSessionName is the session name used when assuming roles in
+// scanned accounts
+const DefaultRoleSessionName = "cloudshaver"
+
+var accountIDPattern = regexp.MustCompile(`^\d{12}$`)
+
+// assumedRole identifies a role assumed with the given options
+type assumedRole struct {
+	arn  string
+	opts AssumeRoleOptions
+}
+
+// assumedCredentials caches the credentials of every assumed role, so a role
+// is assumed once per account rather than once per scanned region
+var assumedCredentials struct {
+	sync.Mutex
+	cache map[assumedRole]*sdkaws.CredentialsCache
+}
+
+// Account identifies an AWS account to scan
+type Account struct {
+	ID    string `json:"id"`
+	Alias string `json:"alias,omitempty"`
+}
+
+// AssumeRoleOptions configures how a role is assumed in a scanned account
+type AssumeRoleOptions struct {
+	// RoleName is the name of the role to assume in every account
+	RoleName string
+	// ExternalID is passed to sts:AssumeRole when set
+	ExternalID string
+	// SessionName defaults to DefaultRoleSessionName
+	SessionName string
+	// STSEndpoint overrides the STS endpoint, e.g. to point at a local STS
+	// stand-in
+	STSEndpoint string
+}
+
+// ParseAccounts parses a static account list. Each entry is a 12-digit
+// account ID, optionally followed by "=alias".
+func ParseAccounts(entries []string) ([]Account, error) {
+	var accounts []Account
+	for _, entry := range entries {
+		id, alias, _ := strings.Cut(strings.TrimSpace(entry), "=")
+		if !accountIDPattern.MatchString(id) {
+			return nil, fmt.Errorf("invalid AWS account ID: %q", id)
+		}
+		accounts = append(accounts, Account{ID: id, Alias: alias})
+	}
+	return accounts, nil
+}
+
+// ListOrganizationAccounts lists the active accounts of the AWS Organization
+// that the credentials in cfg belong to. The account name is used as alias.
+func ListOrganizationAccounts(ctx context.Context, cfg sdkaws.Config) ([]Account, error) {
+	client := organizations.NewFromConfig(cfg)
+	paginator := organizations.NewListAccountsPaginator(client, &organizations.ListAccountsInput{})
+
+	var accounts []Account
+	for paginator.HasMorePages() {
+		page, err := paginator.NextPage(ctx)
+		if err != nil {
+			return nil, fmt.Errorf("failed to list organization accounts: %w", err)
+		}
+		for _, account := range page.Accounts {
+			if account.Status != orgtypes.AccountStatusActive {
+				continue
+			}
+			accounts = append(accounts, Account{
+				ID:    sdkaws.ToString(account.Id),
+				Alias: sdkaws.ToString(account.Name),
+			})
+		}
+	}
+
+	return accounts, nil
+}
+
+// AssumeRoleConfig returns a copy of cfg whose credentials come from assuming
+// the configured role in account. Credentials are fetched lazily and shared
+// by every configuration of the same role, whatever its region.
+func AssumeRoleConfig(cfg sdkaws.Config, account Account, opts AssumeRoleOptions) (sdkaws.Config, error) {
+	if opts.RoleName == "" {
+		return sdkaws.Config{}, fmt.Errorf("a role name is required to scan account %s", account.ID)
+	}
+
+	role := assumedRole{arn: roleARN(cfg.Region, account.ID, opts.RoleName), opts: opts}
+
+	assumedCredentials.Lock()
+	defer assumedCredentials.Unlock()
+
+	if assumedCredentials.cache == nil {
+		assumedCredentials.cache = make(map[assumedRole]*sdkaws.CredentialsCache)
+	}
+	credentials, ok := assumedCredentials.cache[role]
+	if !ok {
+		credentials = newAssumeRoleCredentials(cfg, role.arn, opts)
+		assumedCredentials.cache[role] = credentials
+	}
+
+	assumed := cfg.Copy()
+	assumed.Credentials = credentials
+	return assumed, nil
+}
+
+// newAssumeRoleCredentials returns cached credentials assuming the role arn
+// with the credentials of cfg
+func newAssumeRoleCredentials(cfg sdkaws.Config, arn string, opts AssumeRoleOptions) *sdkaws.CredentialsCache {
+	stsClient := sts.NewFromConfig(cfg, func(o *sts.Options) {
+		if opts.STSEndpoint != "" {
+			o.BaseEndpoint = sdkaws.String(opts.STSEndpoint)
+		}
+	})
+
+	sessionName := opts.SessionName
+	if sessionName == "" {
+		sessionName = DefaultRoleSessionName
+	}
+
+	provider := stscreds.NewAssumeRoleProvider(stsClient, arn, func(o *stscreds.AssumeRoleOptions) {
+		o.RoleSessionName = sessionName
+		if opts.ExternalID != "" {
+			o.ExternalID = sdkaws.String(opts.ExternalID)
+		}
+	})
+	return sdkaws.NewCredentialsCache(provider)
+}
+
+// LookupAccountAlias returns the IAM account alias of the account behind cfg,
+// or an empty string when the account has none
+func LookupAccountAlias(ctx context.Context, cfg sdkaws.Config) (string, error) {
+	output, err := iam.NewFromConfig(cfg).ListAccountAliases(ctx, &iam.ListAccountAliasesInput{})
+	if err != nil {
+		return "", fmt.Errorf("failed to list account aliases: %w", err)
+	}
+	if len(output.AccountAliases) == 0 {
+		return "", nil
+	}
+	return output.AccountAliases[0], nil
+}
+
+// roleARN builds the ARN of a role, using the partition of the region
+func roleARN(region, accountID, roleName string) string {
+	partition := "aws"
+	switch {
+	case strings.HasPrefix(region, "cn-"):
+		partition = "aws-cn"
+	case strings.HasPrefix(region, "us-gov-"):
+		partition = "aws-us-gov"
+	}
+	return fmt.Sprintf("arn:%s:iam::%s:role/%s", partition, accountID, roleName)
+}
@@ -0,0 +1,200 @@
+package aws
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"sync"
+	"testing"
+
+	sdkaws "github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/credentials"
+)
+
+// fakeSTS is a local STS stand-in answering AssumeRole with credentials
+// named after the assumed role
+type fakeSTS struct {
+	mu    sync.Mutex
+	calls []map[string]string
+}
+
+func (f *fakeSTS) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if err := r.ParseForm(); err != nil || r.Form.Get("Action") != "AssumeRole" {
+		http.Error(w, "unexpected request", http.StatusBadRequest)
+		return
+	}
+
+	f.mu.Lock()
+	call := map[string]string{
+		"RoleArn":         r.Form.Get("RoleArn"),
+		"RoleSessionName": r.Form.Get("RoleSessionName"),
+		"ExternalId":      r.Form.Get("ExternalId"),
+	}
+	f.calls = append(f.calls, call)
+	f.mu.Unlock()
+
+	w.Header().Set("Content-Type", "text/xml")
+	fmt.Fprintf(w, `<AssumeRoleResponse xmlns="https://sts.amazonaws.com/doc/2011-06-15/">
+  <AssumeRoleResult>
+    <Credentials>
+      <AccessKeyId>key-for-%s</AccessKeyId>
+      <SecretAccessKey>secret</SecretAccessKey>
+      <SessionToken>token</SessionToken>
+      <Expiration>2099-01-01T00:00:00Z</Expiration>
+    </Credentials>
+    <AssumedRoleUser>
+      <Arn>%s/%s</Arn>
+      <AssumedRoleId>AROA:%s</AssumedRoleId>
+    </AssumedRoleUser>
+  </AssumeRoleResult>
+  <ResponseMetadata><RequestId>request</RequestId></ResponseMetadata>
+</AssumeRoleResponse>`, call["RoleArn"], call["RoleArn"], call["RoleSessionName"], call["RoleSessionName"])
+}
+
+func testConfig(region string) sdkaws.Config {
+	return sdkaws.Config{
+		Region:      region,
+		Credentials: credentials.NewStaticCredentialsProvider("ambient", "secret", ""),
+	}
+}
+
+func TestAssumeRoleConfigAssumesOncePerAccount(t *testing.T) {
+	sts := &fakeSTS{}
+	server := httptest.NewServer(sts)
+	defer server.Close()
+
+	opts := AssumeRoleOptions{
+		RoleName:    "OncePerAccountAuditor",
+		ExternalID:  "external",
+		STSEndpoint: server.URL,
+	}
+	ctx := context.Background()
+
+	for _, target := range []struct {
+		account string
+		region  string
+	}{
+		{"111111111111", "us-east-1"},
+		{"111111111111", "eu-west-1"},
+		{"222222222222", "us-east-1"},
+		{"111111111111", "ap-southeast-2"},
+	} {
+		cfg, err := AssumeRoleConfig(testConfig(target.region), Account{ID: target.account}, opts)
+		if err != nil {
+			t.Fatalf("AssumeRoleConfig(%s, %s): %v", target.account, target.region, err)
+		}
+		if cfg.Region != target.region {
+			t.Errorf("region = %s, want %s", cfg.Region, target.region)
+		}
+
+		creds, err := cfg.Credentials.Retrieve(ctx)
+		if err != nil {
+			t.Fatalf("Retrieve(%s, %s): %v", target.account, target.region, err)
+		}
+		want := "key-for-arn:aws:iam::" + target.account + ":role/OncePerAccountAuditor"
+		if creds.AccessKeyID != want {
+			t.Errorf("AccessKeyID = %s, want %s", creds.AccessKeyID, want)
+		}
+	}
+
+	want := []map[string]string{
+		{"RoleArn": "arn:aws:iam::111111111111:role/OncePerAccountAuditor", "RoleSessionName": DefaultRoleSessionName, "ExternalId": "external"},
+		{"RoleArn": "arn:aws:iam::222222222222:role/OncePerAccountAuditor", "RoleSessionName": DefaultRoleSessionName, "ExternalId": "external"},
+	}
+	if !reflect.DeepEqual(sts.calls, want) {
+		t.Errorf("AssumeRole calls = %v, want %v", sts.calls, want)
+	}
+}
+
+func TestAssumeRoleConfigRequiresRole(t *testing.T) {
+	if _, err := AssumeRoleConfig(testConfig("us-east-1"), Account{ID: "111111111111"}, AssumeRoleOptions{}); err == nil {
+		t.Error("AssumeRoleConfig without a role name succeeded")
+	}
+}
+
+func TestListOrganizationAccounts(t *testing.T) {
+	pages := map[string]string{
+		"": `{"Accounts": [
+			{"Id": "111111111111", "Name": "production", "Status": "ACTIVE"},
+			{"Id": "222222222222", "Name": "closed", "Status": "SUSPENDED"}
+		], "NextToken": "page-2"}`,
+		"page-2": `{"Accounts": [
+			{"Id": "333333333333", "Name": "staging", "Status": "ACTIVE"}
+		]}`,
+	}
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if target := r.Header.Get("X-Amz-Target"); target != "AWSOrganizationsV20161128.ListAccounts" {
+			http.Error(w, "unexpected target "+target, http.StatusBadRequest)
+			return
+		}
+		var input struct{ NextToken string }
+		if err := json.NewDecoder(r.Body).Decode(&input); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		w.Header().Set("Content-Type", "application/x-amz-json-1.1")
+		fmt.Fprint(w, pages[input.NextToken])
+	}))
+	defer server.Close()
+
+	cfg := testConfig("us-east-1")
+	cfg.BaseEndpoint = sdkaws.String(server.URL)
+
+	accounts, err := ListOrganizationAccounts(context.Background(), cfg)
+	if err != nil {
+		t.Fatalf("ListOrganizationAccounts: %v", err)
+	}
+
+	want := []Account{
+		{ID: "111111111111", Alias: "production"},
+		{ID: "333333333333", Alias: "staging"},
+	}
+	if !reflect.DeepEqual(accounts, want) {
+		t.Errorf("accounts = %v, want %v", accounts, want)
+	}
+}
+
+func TestParseAccounts(t *testing.T) {
+	tests := []struct {
+		entries []string
+		want    []Account
+		wantErr bool
+	}{
+		{entries: []string{"111111111111"}, want: []Account{{ID: "111111111111"}}},
+		{entries: []string{" 111111111111=prod ", "222222222222"}, want: []Account{{ID: "111111111111", Alias: "prod"}, {ID: "222222222222"}}},
+		{entries: []string{"11111111111"}, wantErr: true},
+		{entries: []string{"prod=111111111111"}, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		got, err := ParseAccounts(tt.entries)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("ParseAccounts(%q) error = %v, want error %v", tt.entries, err, tt.wantErr)
+			continue
+		}
+		if !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("ParseAccounts(%q) = %v, want %v", tt.entries, got, tt.want)
+		}
+	}
+}
+
+func TestRoleARN(t *testing.T) {
+	tests := []struct {
+		region string
+		want   string
+	}{
+		{"us-east-1", "arn:aws:iam::111111111111:role/Auditor"},
+		{"cn-north-1", "arn:aws-cn:iam::111111111111:role/Auditor"},
+		{"us-gov-west-1", "arn:aws-us-gov:iam::111111111111:role/Auditor"},
+	}
+
+	for _, tt := range tests {
+		if got := roleARN(tt.region, "111111111111", "Auditor"); got != tt.want {
+			t.Errorf("roleARN(%s) = %s, want %s", tt.region, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,34 @@
+package aws
+
+import (
+	"context"
+	"fmt"
+	"sort"
+
+	sdkaws "github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/ec2"
+)
+
+// EnabledRegions lists the regions enabled for the account behind cfg, i.e.
+// the default regions plus any opt-in regions the account has opted into
+func EnabledRegions(ctx context.Context, cfg sdkaws.Config) ([]string, error) {
+	client := ec2.NewFromConfig(cfg)
+
+	output, err := client.DescribeRegions(ctx, &ec2.DescribeRegionsInput{
+		AllRegions: sdkaws.Bool(false),
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to describe regions: %w", err)
+	}
+
+	var regions []string
+	for _, region := range output.Regions {
+		if sdkaws.ToString(region.OptInStatus) == "not-opted-in" {
+			continue
+		}
+		regions = append(regions, sdkaws.ToString(region.RegionName))
+	}
+	sort.Strings(regions)
+
+	return regions, nil
+}
@@ -0,0 +1,58 @@
+package awsblades
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/yourusername/cloudshaver/internal/types"
+)
+
+// bladeAnalysis is one named step of a blade's Execute
+type bladeAnalysis struct {
+	name string
+	run  func(ctx context.Context) ([]types.Finding, error)
+}
+
+// runAnalyses executes the analyses in order and collects their findings into
+// result. A failing analysis does not stop the others; the failures are
+// returned together once every analysis ran, so the scan records them. A
+// cancelled context stops the run and returns an error describing how far it
+// got. Either way the findings collected so far are left in result.
+func runAnalyses(ctx context.Context, bladeName string, result *types.BladeResult, analyses []bladeAnalysis) error {
+	var errs []error
+	for i, analysis := range analyses {
+		if err := ctx.Err(); err != nil {
+			return interruptedError(bladeName, analysis.name, i, len(analyses), len(result.Findings), err)
+		}
+
+		findings, err := analysis.run(ctx)
+		for _, finding := range findings {
+			result.AddFinding(finding)
+		}
+		if err != nil {
+			if ctxErr := ctx.Err(); ctxErr != nil {
+				return interruptedError(bladeName, analysis.name, i, len(analyses), len(result.Findings), ctxErr)
+			}
+			errs = append(errs, fmt.Errorf("%s: %s analysis failed: %w", bladeName, analysis.name, err))
+		}
+	}
+
+	return errors.Join(errs...)
+}
+
+func interruptedError(bladeName, step string, completed, total, findings int, err error) error {
+	return fmt.Errorf("%s cancelled during %s analysis (completed %d of %d analyses, %d findings collected): %w",
+		bladeName, step, completed, total, findings, err)
+}
+
+// summarizeFindings sorts the findings of result and renders them as
+// human-readable recommendations
+func summarizeFindings(result *types.BladeResult) {
+	types.SortFindingsBySavings(result.Findings)
+	for _, finding := range result.Findings {
+		result.Recommendations = append(result.Recommendations,
+			fmt.Sprintf("%s %s: %s (Monthly savings: $%.2f)",
+				finding.ResourceType, finding.ResourceID, finding.Recommendation, finding.Savings))
+	}
+}
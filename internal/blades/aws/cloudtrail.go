@@ -0,0 +1,48 @@
+package awsblades
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/cloudtrail"
+	cttypes "github.com/aws/aws-sdk-go-v2/service/cloudtrail/types"
+)
+
+// cloudTrailLookback is how far back the CloudTrail event history reaches
+const cloudTrailLookback = 90 * 24 * time.Hour
+
+// CloudTrailAPI is the subset of the CloudTrail API used to date stopped
+// instances and unattached volumes. It can be replaced by a local stub to
+// run the idle resource analyses offline.
+type CloudTrailAPI interface {
+	cloudtrail.LookupEventsAPIClient
+}
+
+// latestEvents returns the time of the latest event of each name that
+// CloudTrail recorded for resourceID since start
+func latestEvents(ctx context.Context, client CloudTrailAPI, resourceID string, start time.Time) (map[string]time.Time, error) {
+	paginator := cloudtrail.NewLookupEventsPaginator(client, &cloudtrail.LookupEventsInput{
+		LookupAttributes: []cttypes.LookupAttribute{{
+			AttributeKey:   cttypes.LookupAttributeKeyResourceName,
+			AttributeValue: aws.String(resourceID),
+		}},
+		StartTime: aws.Time(start),
+	})
+
+	latest := make(map[string]time.Time)
+	for paginator.HasMorePages() {
+		page, err := paginator.NextPage(ctx)
+		if err != nil {
+			return nil, fmt.Errorf("failed to look up CloudTrail events of %s: %w", resourceID, err)
+		}
+		for _, event := range page.Events {
+			name, at := aws.ToString(event.EventName), aws.ToTime(event.EventTime)
+			if at.After(latest[name]) {
+				latest[name] = at
+			}
+		}
+	}
+	return latest, nil
+}
@@ -0,0 +1,250 @@
+package awsblades
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"sort"
+	"strconv"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/credentials"
+	"github.com/aws/aws-sdk-go-v2/service/cloudtrail"
+	ec2types "github.com/aws/aws-sdk-go-v2/service/ec2/types"
+	"github.com/yourusername/cloudshaver/internal/types"
+)
+
+// fakeCloudTrail is a local CloudTrail stand-in answering LookupEvents by
+// resource name, one event per page
+type fakeCloudTrail struct {
+	// events holds the event times of each resource by event name
+	events map[string]map[string]time.Time
+
+	mu      sync.Mutex
+	lookups []string
+}
+
+func (f *fakeCloudTrail) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if target := r.Header.Get("X-Amz-Target"); target != "CloudTrail_20131101.LookupEvents" {
+		http.Error(w, "unexpected target "+target, http.StatusBadRequest)
+		return
+	}
+	var input struct {
+		LookupAttributes []struct {
+			AttributeKey   string
+			AttributeValue string
+		}
+		StartTime float64
+		NextToken string
+	}
+	if err := json.NewDecoder(r.Body).Decode(&input); err != nil || len(input.LookupAttributes) != 1 ||
+		input.LookupAttributes[0].AttributeKey != "ResourceName" || input.StartTime == 0 {
+		http.Error(w, "unexpected lookup", http.StatusBadRequest)
+		return
+	}
+	resourceID := input.LookupAttributes[0].AttributeValue
+	start := time.Unix(int64(input.StartTime), 0)
+
+	if input.NextToken == "" {
+		f.mu.Lock()
+		f.lookups = append(f.lookups, resourceID)
+		f.mu.Unlock()
+	}
+
+	type event struct {
+		EventName string
+		EventTime float64
+	}
+	var events []event
+	for name, at := range f.events[resourceID] {
+		if !at.Before(start) {
+			events = append(events, event{EventName: name, EventTime: float64(at.Unix())})
+		}
+	}
+
+	sort.Slice(events, func(i, j int) bool { return events[i].EventName < events[j].EventName })
+
+	page := 0
+	if input.NextToken != "" {
+		page, _ = strconv.Atoi(input.NextToken)
+	}
+	output := map[string]interface{}{"Events": []event{}}
+	if page < len(events) {
+		output["Events"] = events[page : page+1]
+		if page+1 < len(events) {
+			output["NextToken"] = strconv.Itoa(page + 1)
+		}
+	}
+	w.Header().Set("Content-Type", "application/x-amz-json-1.1")
+	json.NewEncoder(w).Encode(output)
+}
+
+// newTestCloudTrail serves fake from a local server and returns a CloudTrail
+// client calling it
+func newTestCloudTrail(t *testing.T, fake *fakeCloudTrail) *cloudtrail.Client {
+	t.Helper()
+	server := httptest.NewServer(fake)
+	t.Cleanup(server.Close)
+
+	return cloudtrail.NewFromConfig(aws.Config{
+		Region:       testRegion,
+		Credentials:  credentials.NewStaticCredentialsProvider("key", "secret", ""),
+		BaseEndpoint: aws.String(server.URL),
+	})
+}
+
+func TestLatestEvents(t *testing.T) {
+	now := time.Now().UTC().Truncate(time.Second)
+	fake := &fakeCloudTrail{events: map[string]map[string]time.Time{
+		"vol-1": {
+			"AttachVolume": now.Add(-48 * time.Hour),
+			"DetachVolume": now.Add(-24 * time.Hour),
+			"CreateVolume": now.Add(-100 * 24 * time.Hour),
+		},
+	}}
+	client := newTestCloudTrail(t, fake)
+
+	events, err := latestEvents(context.Background(), client, "vol-1", now.Add(-cloudTrailLookback))
+	if err != nil {
+		t.Fatalf("latestEvents: %v", err)
+	}
+
+	want := map[string]time.Time{
+		"AttachVolume": now.Add(-48 * time.Hour),
+		"DetachVolume": now.Add(-24 * time.Hour),
+	}
+	if len(events) != len(want) {
+		t.Errorf("events = %v, want %v", events, want)
+	}
+	for name, at := range want {
+		if !events[name].Equal(at) {
+			t.Errorf("%s at %v, want %v", name, events[name], at)
+		}
+	}
+}
+
+func TestAnalyzeUnattachedVolumes(t *testing.T) {
+	now := time.Now().UTC()
+	daysAgo := func(days int) time.Time { return now.Add(-time.Duration(days) * 24 * time.Hour) }
+
+	tests := []struct {
+		name          string
+		created       time.Time
+		events        map[string]time.Time
+		noCloudTrail  bool
+		idleAge       time.Duration
+		wantIdleDays  string
+		wantSource    string
+		wantUnknown   bool
+		wantNoLookups bool
+	}{
+		{
+			name:         "detached long ago",
+			created:      daysAgo(60),
+			events:       map[string]time.Time{"AttachVolume": daysAgo(50), "DetachVolume": daysAgo(30)},
+			wantIdleDays: "30",
+			wantSource:   idleSourceCloudTrail,
+		},
+		{
+			name:    "detached recently",
+			created: daysAgo(60),
+			events:  map[string]time.Time{"AttachVolume": daysAgo(50), "DetachVolume": daysAgo(3)},
+		},
+		{
+			name:        "attached after the last recorded detach",
+			created:     daysAgo(60),
+			events:      map[string]time.Time{"DetachVolume": daysAgo(40), "AttachVolume": daysAgo(30)},
+			wantUnknown: true,
+		},
+		{
+			name:         "never attached",
+			created:      daysAgo(60),
+			wantIdleDays: "60",
+			wantSource:   idleSourceCreation,
+		},
+		{
+			name:         "never attached within the history",
+			created:      daysAgo(200),
+			wantIdleDays: "90",
+			wantSource:   idleSourceCloudTrailLookback,
+		},
+		{
+			name:          "younger than the threshold",
+			created:       daysAgo(2),
+			wantNoLookups: true,
+		},
+		{
+			// The creation time only bounds the detach time from above
+			name:         "without CloudTrail",
+			created:      daysAgo(60),
+			noCloudTrail: true,
+			wantUnknown:  true,
+		},
+		{
+			name:    "detached within the configured idle age",
+			created: daysAgo(60),
+			events:  map[string]time.Time{"AttachVolume": daysAgo(50), "DetachVolume": daysAgo(30)},
+			idleAge: 45 * 24 * time.Hour,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			client := &fakeEC2{volumes: []ec2types.Volume{{
+				VolumeId:   aws.String("vol-1"),
+				VolumeType: ec2types.VolumeTypeGp3,
+				Size:       aws.Int32(100),
+				State:      ec2types.VolumeStateAvailable,
+				CreateTime: aws.Time(tt.created),
+			}}}
+			fake := &fakeCloudTrail{events: map[string]map[string]time.Time{"vol-1": tt.events}}
+			opts := EC2BladeOptions{IdleAge: tt.idleAge}
+			if !tt.noCloudTrail {
+				opts.CloudTrail = newTestCloudTrail(t, fake)
+			}
+			blade := newTestEC2Blade(t, client, opts)
+
+			findings, err := blade.analyzeUnattachedVolumes(context.Background())
+			if err != nil {
+				t.Fatalf("analyzeUnattachedVolumes: %v", err)
+			}
+			if tt.wantNoLookups && len(fake.lookups) > 0 {
+				t.Errorf("looked up %v, want no lookups", fake.lookups)
+			}
+			if tt.wantIdleDays == "" && !tt.wantUnknown {
+				if len(findings) != 0 {
+					t.Errorf("findings = %+v, want none", findings)
+				}
+				return
+			}
+			if len(findings) != 1 {
+				t.Fatalf("%d findings, want 1", len(findings))
+			}
+
+			finding := findings[0]
+			if got := finding.Details["idle_days"]; got != tt.wantIdleDays {
+				t.Errorf("idle days = %s, want %s", got, tt.wantIdleDays)
+			}
+			if got := finding.Details["idle_since_source"]; got != tt.wantSource {
+				t.Errorf("idle since source = %s, want %s", got, tt.wantSource)
+			}
+			wantConfidence := types.ConfidenceHigh
+			if tt.wantUnknown {
+				wantConfidence = types.ConfidenceLow
+				if got := finding.Details["idle_since"]; got != "unknown" {
+					t.Errorf("idle since = %s, want unknown", got)
+				}
+			}
+			if finding.Confidence != wantConfidence {
+				t.Errorf("confidence = %s, want %s", finding.Confidence, wantConfidence)
+			}
+			if want := 100 * 0.08; finding.Savings != want {
+				t.Errorf("savings = %.2f, want %.2f", finding.Savings, want)
+			}
+		})
+	}
+}
@@ -0,0 +1,699 @@
+package awsblades
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/ec2"
+	ec2types "github.com/aws/aws-sdk-go-v2/service/ec2/types"
+	"github.com/aws/aws-sdk-go-v2/service/savingsplans"
+	sptypes "github.com/aws/aws-sdk-go-v2/service/savingsplans/types"
+	"github.com/sirupsen/logrus"
+	"github.com/yourusername/cloudshaver/internal/inventory"
+	awspricing "github.com/yourusername/cloudshaver/internal/pricing/aws"
+	pricingclient "github.com/yourusername/cloudshaver/internal/pricing/client"
+	"github.com/yourusername/cloudshaver/internal/registry"
+	"github.com/yourusername/cloudshaver/internal/types"
+)
+
+func init() {
+	registry.Register(registry.Registration{
+		Name:        "commitment-coverage",
+		Description: "Reserved Instance and Savings Plans coverage gaps, idle and expiring commitments",
+		Provider:    types.AWS,
+		Category:    types.CommitmentOptimization,
+		Services:    []string{"ec2", "savingsplans", "pricing"},
+		New: func(ctx context.Context, env registry.Env) (types.Blade, error) {
+			return NewCommitmentBlade(ctx, env.EC2Inventory, CommitmentBladeOptions{
+				ReservedInstances: ec2.NewFromConfig(env.AWSConfig),
+				SavingsPlans:      savingsplans.NewFromConfig(env.AWSConfig),
+				SteadyStateAge:    env.SteadyStateAge,
+				AccountID:         env.Account.ID,
+				PricingSource:     env.PricingSource,
+				Pricing:           env.Pricing,
+			})
+		},
+	})
+}
+
+// commitmentExpiryWindow is how far ahead expiring commitments are reported
+const commitmentExpiryWindow = 30 * 24 * time.Hour
+
+// DefaultSteadyStateAge is how long an instance must have been running to
+// count as steady-state usage when no age is configured
+const DefaultSteadyStateAge = 14 * 24 * time.Hour
+
+// ReservedInstancesAPI is the subset of the EC2 API used to list reservations
+type ReservedInstancesAPI interface {
+	DescribeReservedInstances(ctx context.Context, params *ec2.DescribeReservedInstancesInput, optFns ...func(*ec2.Options)) (*ec2.DescribeReservedInstancesOutput, error)
+}
+
+// SavingsPlansAPI is the subset of the Savings Plans API used by the blades
+type SavingsPlansAPI interface {
+	DescribeSavingsPlans(ctx context.Context, params *savingsplans.DescribeSavingsPlansInput, optFns ...func(*savingsplans.Options)) (*savingsplans.DescribeSavingsPlansOutput, error)
+}
+
+// CommitmentBladeOptions configures the commitment coverage blade
+type CommitmentBladeOptions struct {
+	ReservedInstances ReservedInstancesAPI
+	SavingsPlans      SavingsPlansAPI
+	// SteadyStateAge is how long an instance must have been running to count
+	// as steady-state usage; DefaultSteadyStateAge is used when zero
+	SteadyStateAge time.Duration
+	// AccountID is the scanned account, which owns the commitments; it is
+	// empty when scanning with the ambient credentials
+	AccountID string
+	// PricingSource supplies price lists; nil downloads them with the
+	// default disk cache
+	PricingSource pricingclient.Source
+	// Pricing prices resources instead of the offer files of PricingSource
+	// when set, e.g. with fake prices in tests
+	Pricing awspricing.PriceProvider
+}
+
+// CommitmentBlade compares steady-state instance usage with the active
+// Reserved Instances and Savings Plans of an account and region. Compute
+// Savings Plans apply in every region, so their commitment is counted against
+// each scanned region, which keeps purchase recommendations conservative.
+type CommitmentBlade struct {
+	inventory         *inventory.EC2Inventory
+	pricingService    awspricing.PriceProvider
+	reservedInstances ReservedInstancesAPI
+	savingsPlans      SavingsPlansAPI
+	steadyStateAge    time.Duration
+	accountID         string
+	region            string
+	now               time.Time
+}
+
+// steadyInstance is a long-running instance and how it is covered
+type steadyInstance struct {
+	inventory.Instance
+	instanceType   string
+	family         string
+	size           string
+	platform       awspricing.Platform
+	onDemandHourly float64
+	coveredBy      string
+}
+
+// purchaseOptions are the commitments considered for uncovered usage
+var purchaseOptions = []awspricing.CommitmentTerms{
+	{Kind: awspricing.CommitmentReservedInstance, Term: awspricing.Term1Year, PurchaseOption: awspricing.NoUpfront, OfferingClass: awspricing.OfferingClassStandard},
+	{Kind: awspricing.CommitmentReservedInstance, Term: awspricing.Term1Year, PurchaseOption: awspricing.PartialUpfront, OfferingClass: awspricing.OfferingClassStandard},
+	{Kind: awspricing.CommitmentReservedInstance, Term: awspricing.Term1Year, PurchaseOption: awspricing.AllUpfront, OfferingClass: awspricing.OfferingClassStandard},
+	{Kind: awspricing.CommitmentEC2InstanceSavingsPlan, Term: awspricing.Term1Year, PurchaseOption: awspricing.NoUpfront},
+	{Kind: awspricing.CommitmentEC2InstanceSavingsPlan, Term: awspricing.Term1Year, PurchaseOption: awspricing.PartialUpfront},
+	{Kind: awspricing.CommitmentEC2InstanceSavingsPlan, Term: awspricing.Term1Year, PurchaseOption: awspricing.AllUpfront},
+	{Kind: awspricing.CommitmentComputeSavingsPlan, Term: awspricing.Term1Year, PurchaseOption: awspricing.NoUpfront},
+	{Kind: awspricing.CommitmentComputeSavingsPlan, Term: awspricing.Term1Year, PurchaseOption: awspricing.PartialUpfront},
+	{Kind: awspricing.CommitmentComputeSavingsPlan, Term: awspricing.Term1Year, PurchaseOption: awspricing.AllUpfront},
+}
+
+func NewCommitmentBlade(ctx context.Context, inv *inventory.EC2Inventory, opts CommitmentBladeOptions) (*CommitmentBlade, error) {
+	pricingService := opts.Pricing
+	if pricingService == nil {
+		service, err := awspricing.NewEC2PricingService(ctx, opts.PricingSource)
+		if err != nil {
+			return nil, fmt.Errorf("failed to create pricing service: %w", err)
+		}
+		pricingService = service
+	}
+
+	steadyStateAge := opts.SteadyStateAge
+	if steadyStateAge <= 0 {
+		steadyStateAge = DefaultSteadyStateAge
+	}
+
+	return &CommitmentBlade{
+		inventory:         inv,
+		pricingService:    pricingService,
+		reservedInstances: opts.ReservedInstances,
+		savingsPlans:      opts.SavingsPlans,
+		steadyStateAge:    steadyStateAge,
+		accountID:         opts.AccountID,
+		region:            inv.Region(),
+	}, nil
+}
+
+func (b *CommitmentBlade) GetName() string {
+	return "Commitment Coverage Blade"
+}
+
+func (b *CommitmentBlade) GetCategory() string {
+	return string(types.CommitmentOptimization)
+}
+
+func (b *CommitmentBlade) Execute(ctx context.Context) (*types.BladeResult, error) {
+	result := &types.BladeResult{
+		CloudProvider:    string(types.AWS),
+		Category:         string(types.CommitmentOptimization),
+		ResourceType:     "EC2 Commitments",
+		PotentialSavings: 0,
+		Recommendations:  []string{},
+		Details:          make(map[string]string),
+		Findings:         []types.Finding{},
+		Timestamp:        time.Now(),
+	}
+	b.now = result.Timestamp
+
+	// The analyses share the coverage state, so each one builds on the last.
+	// Without the usage every reservation would look idle, so the later
+	// analyses are skipped when it cannot be listed.
+	var instances []*steadyInstance
+	var usageKnown bool
+	afterUsage := func(analyze func(context.Context, []*steadyInstance) ([]types.Finding, error)) func(context.Context) ([]types.Finding, error) {
+		return func(ctx context.Context) ([]types.Finding, error) {
+			if !usageKnown {
+				return nil, nil
+			}
+			return analyze(ctx, instances)
+		}
+	}
+	err := runAnalyses(ctx, b.GetName(), result, []bladeAnalysis{
+		{name: "steady-state usage", run: func(ctx context.Context) ([]types.Finding, error) {
+			var err error
+			instances, err = b.steadyStateInstances(ctx)
+			usageKnown = err == nil
+			return nil, err
+		}},
+		{name: "reserved instances", run: afterUsage(b.analyzeReservedInstances)},
+		{name: "savings plans", run: afterUsage(b.analyzeSavingsPlans)},
+		{name: "coverage gaps", run: afterUsage(b.analyzeCoverageGaps)},
+	})
+
+	var covered int
+	for _, instance := range instances {
+		if instance.coveredBy != "" {
+			covered++
+		}
+	}
+	result.Details["steady_state_instances"] = strconv.Itoa(len(instances))
+	result.Details["covered_instances"] = strconv.Itoa(covered)
+
+	summarizeFindings(result)
+	return result, err
+}
+
+// steadyStateInstances returns the on-demand instances on shared hardware
+// that have been running for at least the steady-state age, priced for their
+// platform. Instances that cannot be priced are kept with a zero price so
+// they still count against commitments.
+func (b *CommitmentBlade) steadyStateInstances(ctx context.Context) ([]*steadyInstance, error) {
+	running, err := b.inventory.InstancesInState(ctx, ec2types.InstanceStateNameRunning)
+	if err != nil {
+		return nil, err
+	}
+
+	cutoff := b.now.Add(-b.steadyStateAge)
+	var instances []*steadyInstance
+	for _, instance := range running {
+		if instance.InstanceLifecycle != "" ||
+			(instance.Placement != nil && instance.Placement.Tenancy != "" && instance.Placement.Tenancy != ec2types.TenancyDefault) ||
+			instance.LaunchTime == nil || instance.LaunchTime.After(cutoff) {
+			continue
+		}
+
+		instanceType := string(instance.InstanceType)
+		family, size, _ := strings.Cut(instanceType, ".")
+		platform := instancePlatform(instance)
+
+		price, err := b.pricingService.GetInstancePrice(ctx, instanceType, b.region, platform)
+		if err != nil {
+			if ctx.Err() != nil {
+				return instances, ctx.Err()
+			}
+			logrus.WithError(err).Errorf("Failed to get price for instance %s", aws.ToString(instance.InstanceId))
+		}
+
+		instances = append(instances, &steadyInstance{
+			Instance:       instance,
+			instanceType:   instanceType,
+			family:         family,
+			size:           size,
+			platform:       platform,
+			onDemandHourly: price,
+		})
+	}
+
+	return instances, nil
+}
+
+// analyzeReservedInstances applies the active reservations to the steady
+// instances and reports the idle and expiring ones. Regional Linux
+// reservations with default tenancy are size-flexible within their family.
+func (b *CommitmentBlade) analyzeReservedInstances(ctx context.Context, instances []*steadyInstance) ([]types.Finding, error) {
+	if b.reservedInstances == nil {
+		return nil, nil
+	}
+
+	output, err := b.reservedInstances.DescribeReservedInstances(ctx, &ec2.DescribeReservedInstancesInput{
+		Filters: []ec2types.Filter{{
+			Name:   aws.String("state"),
+			Values: []string{string(ec2types.ReservedInstanceStateActive)},
+		}},
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to describe reserved instances: %w", err)
+	}
+
+	var findings []types.Finding
+
+	for _, reservation := range output.ReservedInstances {
+		reservationID := aws.ToString(reservation.ReservedInstancesId)
+		instanceType := string(reservation.InstanceType)
+		family, size, _ := strings.Cut(instanceType, ".")
+		count := float64(aws.ToInt32(reservation.InstanceCount))
+		platform := strings.TrimSuffix(string(reservation.ProductDescription), " (Amazon VPC)")
+		zone := ""
+		if reservation.Scope == ec2types.ScopeAvailabilityZone {
+			zone = aws.ToString(reservation.AvailabilityZone)
+		}
+
+		flexible := zone == "" && platform == awspricing.PlatformLinux.Name &&
+			(reservation.InstanceTenancy == "" || reservation.InstanceTenancy == ec2types.TenancyDefault) &&
+			normalizationFactor(size) > 0
+
+		// Capacity is counted in normalized units when size-flexible and in
+		// instances otherwise
+		capacity := count
+		if flexible {
+			capacity = count * normalizationFactor(size)
+		}
+		remaining := capacity
+
+		var onDemandCovered float64
+		for _, instance := range instances {
+			if instance.coveredBy != "" || instance.platform.Name != platform {
+				continue
+			}
+			if zone != "" && (instance.Placement == nil || aws.ToString(instance.Placement.AvailabilityZone) != zone) {
+				continue
+			}
+
+			units := 1.0
+			if flexible {
+				if instance.family != family {
+					continue
+				}
+				units = normalizationFactor(instance.size)
+			} else if instance.instanceType != instanceType {
+				continue
+			}
+			if units <= 0 || units > remaining {
+				continue
+			}
+
+			instance.coveredBy = reservationID
+			remaining -= units
+			onDemandCovered += instance.onDemandHourly
+		}
+
+		effectiveHourly := reservedEffectiveHourly(reservation)
+		totalCost := effectiveHourly * count * hoursPerMonth
+
+		if remaining > 0 && capacity > 0 {
+			idleFraction := remaining / capacity
+			wasted := totalCost * idleFraction
+			findings = append(findings, types.Finding{
+				ResourceID:   reservationID,
+				ResourceARN:  ec2ARN(b.region, b.accountID, "reserved-instances", reservationID),
+				ResourceType: "Reserved Instance",
+				Region:       b.region,
+				AccountID:    b.accountID,
+				Kind:         types.FindingIdleCommitment,
+				Recommendation: fmt.Sprintf("%.0f%% of reservation for %d x %s (%s) is unused; move matching workloads onto it, modify it or sell it on the Reserved Instance Marketplace",
+					idleFraction*100, int(count), instanceType, platform),
+				CurrentCost:   wasted,
+				ProjectedCost: 0,
+				Savings:       wasted,
+				Confidence:    types.ConfidenceMedium,
+				Details: map[string]string{
+					"instance_type":    instanceType,
+					"instance_count":   strconv.Itoa(int(count)),
+					"platform":         platform,
+					"scope":            string(reservation.Scope),
+					"size_flexible":    strconv.FormatBool(flexible),
+					"idle_percent":     fmt.Sprintf("%.1f", idleFraction*100),
+					"effective_hourly": fmt.Sprintf("%.4f", effectiveHourly),
+					"end":              formatTime(reservation.End),
+				},
+			})
+		}
+
+		if end := aws.ToTime(reservation.End); !end.IsZero() && end.Before(b.now.Add(commitmentExpiryWindow)) && onDemandCovered > 0 {
+			covered := effectiveHourly * count * (1 - remaining/capacity)
+			atRisk := (onDemandCovered - covered) * hoursPerMonth
+			if atRisk > 0 {
+				findings = append(findings, b.expiringCommitmentFinding(reservationID,
+					ec2ARN(b.region, b.accountID, "reserved-instances", reservationID), b.accountID, "Reserved Instance",
+					fmt.Sprintf("%d x %s (%s)", int(count), instanceType, platform), end, atRisk, covered*hoursPerMonth))
+			}
+		}
+	}
+
+	return findings, nil
+}
+
+// analyzeSavingsPlans applies the active Savings Plans to the instances not
+// covered by reservations and reports idle and expiring plans. EC2 Instance
+// Savings Plans are applied first as they are the most specific.
+func (b *CommitmentBlade) analyzeSavingsPlans(ctx context.Context, instances []*steadyInstance) ([]types.Finding, error) {
+	if b.savingsPlans == nil {
+		return nil, nil
+	}
+
+	plans, err := b.activeSavingsPlans(ctx)
+	if err != nil {
+		return nil, err
+	}
+	sort.SliceStable(plans, func(i, j int) bool {
+		return plans[i].SavingsPlanType == sptypes.SavingsPlanTypeEc2Instance &&
+			plans[j].SavingsPlanType != sptypes.SavingsPlanTypeEc2Instance
+	})
+
+	var findings []types.Finding
+
+	for _, plan := range plans {
+		planID := aws.ToString(plan.SavingsPlanId)
+		planARN := aws.ToString(plan.SavingsPlanArn)
+		family := aws.ToString(plan.Ec2InstanceFamily)
+		// Savings Plans may be bought by the management account of an
+		// organization, so the plan's own account is preferred
+		accountID := arnAccountID(planARN)
+		if accountID == "" {
+			accountID = b.accountID
+		}
+
+		var kind awspricing.CommitmentKind
+		switch plan.SavingsPlanType {
+		case sptypes.SavingsPlanTypeCompute:
+			kind = awspricing.CommitmentComputeSavingsPlan
+		case sptypes.SavingsPlanTypeEc2Instance:
+			if aws.ToString(plan.Region) != b.region {
+				continue
+			}
+			kind = awspricing.CommitmentEC2InstanceSavingsPlan
+		default:
+			continue
+		}
+
+		commitment, err := strconv.ParseFloat(aws.ToString(plan.Commitment), 64)
+		if err != nil {
+			logrus.WithError(err).Warnf("Failed to parse commitment of Savings Plan %s", planID)
+			continue
+		}
+
+		term := awspricing.Term1Year
+		if plan.TermDurationInSeconds > 366*24*3600 {
+			term = awspricing.Term3Year
+		}
+		terms := awspricing.CommitmentTerms{Kind: kind, Term: term, PurchaseOption: string(plan.PaymentOption)}
+
+		remaining := commitment
+		var onDemandCovered float64
+		for _, instance := range instances {
+			if instance.coveredBy != "" || (family != "" && instance.family != family) {
+				continue
+			}
+
+			price, err := b.pricingService.GetCommitmentPrice(ctx, instance.instanceType, b.region, instance.platform, terms)
+			if err != nil {
+				if ctx.Err() != nil {
+					return findings, ctx.Err()
+				}
+				logrus.WithError(err).Debugf("No Savings Plans rate for %s", instance.instanceType)
+				continue
+			}
+			if price.EffectiveHourly > remaining {
+				continue
+			}
+
+			instance.coveredBy = planID
+			remaining -= price.EffectiveHourly
+			onDemandCovered += instance.onDemandHourly
+		}
+
+		// A Compute Savings Plan may be used up in regions not scanned here,
+		// so only regional EC2 Instance Savings Plans are reported idle
+		if kind == awspricing.CommitmentEC2InstanceSavingsPlan && remaining > 0 {
+			wasted := remaining * hoursPerMonth
+			findings = append(findings, types.Finding{
+				ResourceID:   planID,
+				ResourceARN:  planARN,
+				ResourceType: "Savings Plan",
+				Region:       b.region,
+				AccountID:    accountID,
+				Kind:         types.FindingIdleCommitment,
+				Recommendation: fmt.Sprintf("$%.4f of the $%.4f/hour %s family commitment is unused; move %s workloads in %s onto it",
+					remaining, commitment, family, family, b.region),
+				CurrentCost:   wasted,
+				ProjectedCost: 0,
+				Savings:       wasted,
+				Confidence:    types.ConfidenceMedium,
+				Details: map[string]string{
+					"savings_plan_type": string(plan.SavingsPlanType),
+					"instance_family":   family,
+					"commitment_hourly": fmt.Sprintf("%.4f", commitment),
+					"unused_hourly":     fmt.Sprintf("%.4f", remaining),
+					"payment_option":    string(plan.PaymentOption),
+					"end":               aws.ToString(plan.End),
+				},
+			})
+		}
+
+		end, err := time.Parse(time.RFC3339, aws.ToString(plan.End))
+		if err == nil && end.Before(b.now.Add(commitmentExpiryWindow)) && onDemandCovered > 0 {
+			used := commitment - remaining
+			if atRisk := (onDemandCovered - used) * hoursPerMonth; atRisk > 0 {
+				findings = append(findings, b.expiringCommitmentFinding(planID, planARN, accountID, "Savings Plan",
+					fmt.Sprintf("$%.4f/hour %s Savings Plan", commitment, plan.SavingsPlanType), end, atRisk, used*hoursPerMonth))
+			}
+		}
+	}
+
+	return findings, nil
+}
+
+// activeSavingsPlans lists every active Savings Plan of the account
+func (b *CommitmentBlade) activeSavingsPlans(ctx context.Context) ([]sptypes.SavingsPlan, error) {
+	input := &savingsplans.DescribeSavingsPlansInput{
+		States: []sptypes.SavingsPlanState{sptypes.SavingsPlanStateActive},
+	}
+
+	var plans []sptypes.SavingsPlan
+	for {
+		output, err := b.savingsPlans.DescribeSavingsPlans(ctx, input)
+		if err != nil {
+			return nil, fmt.Errorf("failed to describe savings plans: %w", err)
+		}
+		plans = append(plans, output.SavingsPlans...)
+		if aws.ToString(output.NextToken) == "" {
+			return plans, nil
+		}
+		input.NextToken = output.NextToken
+	}
+}
+
+// analyzeCoverageGaps recommends one commitment purchase per instance family
+// and platform for the steady instances left uncovered, choosing the 1-year
+// offering with the largest savings
+func (b *CommitmentBlade) analyzeCoverageGaps(ctx context.Context, instances []*steadyInstance) ([]types.Finding, error) {
+	type coverageGap struct {
+		family    string
+		platform  awspricing.Platform
+		instances []*steadyInstance
+	}
+
+	gaps := make(map[string]*coverageGap)
+	var keys []string
+	for _, instance := range instances {
+		if instance.coveredBy != "" || instance.onDemandHourly <= 0 {
+			continue
+		}
+		key := instance.family + "/" + instance.platform.Operation
+		if _, ok := gaps[key]; !ok {
+			gaps[key] = &coverageGap{family: instance.family, platform: instance.platform}
+			keys = append(keys, key)
+		}
+		gaps[key].instances = append(gaps[key].instances, instance)
+	}
+	sort.Strings(keys)
+
+	var findings []types.Finding
+
+	for _, key := range keys {
+		gap := gaps[key]
+
+		var onDemandHourly float64
+		typeCounts := make(map[string]int)
+		for _, instance := range gap.instances {
+			onDemandHourly += instance.onDemandHourly
+			typeCounts[instance.instanceType]++
+		}
+
+		var best *awspricing.CommitmentPrice
+		for _, terms := range purchaseOptions {
+			total, err := b.priceCommitment(ctx, gap.instances, terms)
+			if err != nil {
+				if ctx.Err() != nil {
+					return findings, ctx.Err()
+				}
+				logrus.WithError(err).Debugf("No %s %s pricing for %s", terms.Kind, terms.PurchaseOption, key)
+				continue
+			}
+			if best == nil || total.EffectiveHourly < best.EffectiveHourly ||
+				(total.EffectiveHourly == best.EffectiveHourly && total.Upfront < best.Upfront) {
+				best = &total
+			}
+		}
+		if best == nil || best.EffectiveHourly >= onDemandHourly {
+			continue
+		}
+
+		// Months until the avoided on-demand charges repay the upfront amount
+		var breakEven float64
+		if best.Upfront > 0 {
+			breakEven = best.Upfront / ((onDemandHourly - best.Hourly) * hoursPerMonth)
+		}
+
+		var covered []string
+		for instanceType, count := range typeCounts {
+			covered = append(covered, fmt.Sprintf("%d x %s", count, instanceType))
+		}
+		sort.Strings(covered)
+
+		var purchase string
+		switch best.Kind {
+		case awspricing.CommitmentReservedInstance:
+			purchase = fmt.Sprintf("%s %s %s Reserved Instances for %s", best.Term, best.PurchaseOption, best.OfferingClass, strings.Join(covered, ", "))
+		default:
+			purchase = fmt.Sprintf("a %s %s %s committing $%.4f/hour to cover %s", best.Term, best.PurchaseOption, best.Kind, best.EffectiveHourly, strings.Join(covered, ", "))
+		}
+
+		currentCost := onDemandHourly * hoursPerMonth
+		projectedCost := best.EffectiveHourly * hoursPerMonth
+		findings = append(findings, types.Finding{
+			ResourceID:   gap.family + "/" + gap.platform.Name,
+			ResourceType: "Commitment Purchase",
+			Region:       b.region,
+			AccountID:    gap.instances[0].OwnerID,
+			Kind:         types.FindingCommitmentPurchase,
+			Recommendation: fmt.Sprintf("Purchase %s (break-even after %.1f months)",
+				purchase, breakEven),
+			CurrentCost:   currentCost,
+			ProjectedCost: projectedCost,
+			Savings:       currentCost - projectedCost,
+			Confidence:    types.ConfidenceMedium,
+			Details: map[string]string{
+				"instance_family":      gap.family,
+				"platform":             gap.platform.Name,
+				"instances":            strings.Join(covered, ", "),
+				"commitment_kind":      string(best.Kind),
+				"term":                 best.Term,
+				"purchase_option":      best.PurchaseOption,
+				"offering_class":       best.OfferingClass,
+				"upfront":              fmt.Sprintf("%.2f", best.Upfront),
+				"recurring_hourly":     fmt.Sprintf("%.4f", best.Hourly),
+				"effective_hourly":     fmt.Sprintf("%.4f", best.EffectiveHourly),
+				"on_demand_hourly":     fmt.Sprintf("%.4f", onDemandHourly),
+				"break_even_months":    fmt.Sprintf("%.1f", breakEven),
+				"steady_state_minimum": b.steadyStateAge.String(),
+			},
+		})
+	}
+
+	return findings, nil
+}
+
+// priceCommitment sums the commitment prices of a group of instances
+func (b *CommitmentBlade) priceCommitment(ctx context.Context, instances []*steadyInstance, terms awspricing.CommitmentTerms) (awspricing.CommitmentPrice, error) {
+	total := awspricing.CommitmentPrice{CommitmentTerms: terms}
+	for _, instance := range instances {
+		price, err := b.pricingService.GetCommitmentPrice(ctx, instance.instanceType, b.region, instance.platform, terms)
+		if err != nil {
+			return awspricing.CommitmentPrice{}, err
+		}
+		total.Upfront += price.Upfront
+		total.Hourly += price.Hourly
+		total.EffectiveHourly += price.EffectiveHourly
+	}
+	return total, nil
+}
+
+// expiringCommitmentFinding reports a commitment that ends within the expiry
+// window while still covering usage that would revert to on-demand prices.
+// The current cost is that usage at on-demand prices once the commitment
+// ends, and the projected cost is what it costs under a renewal.
+func (b *CommitmentBlade) expiringCommitmentFinding(id, arn, accountID, resourceType, description string, end time.Time, atRisk, renewedCost float64) types.Finding {
+	return types.Finding{
+		ResourceID:   id,
+		ResourceARN:  arn,
+		ResourceType: resourceType,
+		Region:       b.region,
+		AccountID:    accountID,
+		Kind:         types.FindingExpiringCommitment,
+		Recommendation: fmt.Sprintf("%s expires on %s; renew it or plan a replacement to keep the covered usage off on-demand prices",
+			description, end.Format("2006-01-02")),
+		CurrentCost:   renewedCost + atRisk,
+		ProjectedCost: renewedCost,
+		Savings:       atRisk,
+		Confidence:    types.ConfidenceHigh,
+		Details: map[string]string{
+			"expires":              end.Format(time.RFC3339),
+			"monthly_cost_at_risk": fmt.Sprintf("%.2f", atRisk),
+		},
+	}
+}
+
+// reservedEffectiveHourly is the hourly price of one reserved instance with
+// its upfront payment spread over the term
+func reservedEffectiveHourly(reservation ec2types.ReservedInstances) float64 {
+	hourly := float64(aws.ToFloat32(reservation.UsagePrice))
+	for _, charge := range reservation.RecurringCharges {
+		if charge.Frequency == ec2types.RecurringChargeFrequencyHourly {
+			hourly += aws.ToFloat64(charge.Amount)
+		}
+	}
+	if hours := float64(aws.ToInt64(reservation.Duration)) / 3600; hours > 0 {
+		hourly += float64(aws.ToFloat32(reservation.FixedPrice)) / hours
+	}
+	return hourly
+}
+
+// normalizationFactor returns the size-flexibility weight of an instance
+// size, or zero for sizes such as metal whose weight depends on the family
+func normalizationFactor(size string) float64 {
+	switch size {
+	case "nano":
+		return 0.25
+	case "micro":
+		return 0.5
+	case "small":
+		return 1
+	case "medium":
+		return 2
+	case "large":
+		return 4
+	case "xlarge":
+		return 8
+	}
+	if multiple, ok := strings.CutSuffix(size, "xlarge"); ok {
+		if n, err := strconv.Atoi(multiple); err == nil {
+			return float64(8 * n)
+		}
+	}
+	return 0
+}
+
+func formatTime(t *time.Time) string {
+	if t == nil {
+		return ""
+	}
+	return t.Format(time.RFC3339)
+}
@@ -0,0 +1,163 @@
+package awsblades
+
+import (
+	"context"
+	"math"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	ec2types "github.com/aws/aws-sdk-go-v2/service/ec2/types"
+	"github.com/yourusername/cloudshaver/internal/types"
+)
+
+func newTestCommitmentBlade(t *testing.T, client *fakeEC2, opts CommitmentBladeOptions) *CommitmentBlade {
+	t.Helper()
+	opts.ReservedInstances = client
+	opts.Pricing = newFakePrices(t)
+	blade, err := NewCommitmentBlade(context.Background(), client.inventory(), opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return blade
+}
+
+// expiringReservation is a regional reservation of two t3.micro ending in
+// ten days
+func expiringReservation(now time.Time) ec2types.ReservedInstances {
+	return ec2types.ReservedInstances{
+		ReservedInstancesId: aws.String("ri-1"),
+		InstanceType:        ec2types.InstanceTypeT3Micro,
+		InstanceCount:       aws.Int32(2),
+		ProductDescription:  ec2types.RIProductDescription("Linux/UNIX"),
+		Scope:               ec2types.ScopeRegional,
+		UsagePrice:          aws.Float32(0.006),
+		State:               ec2types.ReservedInstanceStateActive,
+		End:                 aws.Time(now.Add(10 * 24 * time.Hour)),
+	}
+}
+
+func TestCommitmentBladeAttributesFindingsToAccount(t *testing.T) {
+	now := time.Now()
+	client := &fakeEC2{
+		instances:         []ec2types.Instance{runningInstance("i-1", "t3.micro", now.Add(-60*24*time.Hour))},
+		reservedInstances: []ec2types.ReservedInstances{expiringReservation(now)},
+	}
+	blade := newTestCommitmentBlade(t, client, CommitmentBladeOptions{AccountID: testAccountID})
+
+	result, err := blade.Execute(context.Background())
+	if err != nil {
+		t.Fatalf("Execute: %v", err)
+	}
+
+	for _, kind := range []types.FindingKind{types.FindingIdleCommitment, types.FindingExpiringCommitment} {
+		findings := findingsOfKind(result.Findings, kind)
+		if len(findings) != 1 {
+			t.Errorf("%d %s findings, want 1", len(findings), kind)
+			continue
+		}
+		finding := findings[0]
+		if finding.AccountID != testAccountID {
+			t.Errorf("%s account = %q, want %s", kind, finding.AccountID, testAccountID)
+		}
+		if want := "arn:aws:ec2:us-east-1:111111111111:reserved-instances/ri-1"; finding.ResourceARN != want {
+			t.Errorf("%s ARN = %q, want %s", kind, finding.ResourceARN, want)
+		}
+	}
+}
+
+func TestCommitmentBladeSteadyStateAge(t *testing.T) {
+	now := time.Now()
+
+	tests := []struct {
+		name           string
+		steadyStateAge time.Duration
+		wantSteady     string
+		wantIdle       string
+	}{
+		{name: "default", wantSteady: "1", wantIdle: "50.0"},
+		{name: "longer than the instance ran", steadyStateAge: 30 * 24 * time.Hour, wantSteady: "0", wantIdle: "100.0"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			client := &fakeEC2{
+				instances:         []ec2types.Instance{runningInstance("i-1", "t3.micro", now.Add(-20*24*time.Hour))},
+				reservedInstances: []ec2types.ReservedInstances{expiringReservation(now)},
+			}
+			blade := newTestCommitmentBlade(t, client, CommitmentBladeOptions{SteadyStateAge: tt.steadyStateAge})
+
+			result, err := blade.Execute(context.Background())
+			if err != nil {
+				t.Fatalf("Execute: %v", err)
+			}
+			if got := result.Details["steady_state_instances"]; got != tt.wantSteady {
+				t.Errorf("steady-state instances = %s, want %s", got, tt.wantSteady)
+			}
+			idle := findingsOfKind(result.Findings, types.FindingIdleCommitment)
+			if len(idle) != 1 || idle[0].Details["idle_percent"] != tt.wantIdle {
+				t.Errorf("idle findings = %+v, want one %s%% idle", idle, tt.wantIdle)
+			}
+		})
+	}
+}
+
+func TestCommitmentBladeExpiringSavings(t *testing.T) {
+	now := time.Now()
+	client := &fakeEC2{
+		instances:         []ec2types.Instance{runningInstance("i-1", "t3.micro", now.Add(-60*24*time.Hour))},
+		reservedInstances: []ec2types.ReservedInstances{expiringReservation(now)},
+	}
+	blade := newTestCommitmentBlade(t, client, CommitmentBladeOptions{})
+
+	result, err := blade.Execute(context.Background())
+	if err != nil {
+		t.Fatalf("Execute: %v", err)
+	}
+
+	// one of the two reserved t3.micro covers the instance at $0.006/hour
+	// instead of $0.0104/hour on demand
+	expiring := findingsOfKind(result.Findings, types.FindingExpiringCommitment)
+	if len(expiring) != 1 {
+		t.Fatalf("%d expiring findings, want 1", len(expiring))
+	}
+	finding := expiring[0]
+	if want := 0.0104 * hoursPerMonth; math.Abs(finding.CurrentCost-want) > 1e-6 {
+		t.Errorf("current cost = %.4f, want the on-demand cost %.4f", finding.CurrentCost, want)
+	}
+	if want := 0.006 * hoursPerMonth; math.Abs(finding.ProjectedCost-want) > 1e-6 {
+		t.Errorf("projected cost = %.4f, want the renewed cost %.4f", finding.ProjectedCost, want)
+	}
+
+	var savings float64
+	for _, finding := range result.Findings {
+		if math.Abs(finding.Savings-(finding.CurrentCost-finding.ProjectedCost)) > 1e-6 {
+			t.Errorf("%s savings = %.4f, want current %.4f - projected %.4f",
+				finding.Kind, finding.Savings, finding.CurrentCost, finding.ProjectedCost)
+		}
+		savings += finding.Savings
+	}
+	// the idle half of the reservation and the on-demand premium at expiry
+	if want := (0.006 + (0.0104 - 0.006)) * hoursPerMonth; math.Abs(result.PotentialSavings-want) > 1e-6 || math.Abs(savings-want) > 1e-6 {
+		t.Errorf("potential savings = %.4f, findings save %.4f, want %.4f", result.PotentialSavings, savings, want)
+	}
+}
+
+func TestArnAccountID(t *testing.T) {
+	tests := []struct {
+		arn  string
+		want string
+	}{
+		{"arn:aws:savingsplans::222222222222:savingsplan/sp-1", "222222222222"},
+		{"arn:aws:ec2:us-east-1:111111111111:instance/i-1", "111111111111"},
+		{"arn:aws:ec2:us-east-1::snapshot/snap-1", ""},
+		{"sp-1", ""},
+		{"", ""},
+	}
+
+	for _, tt := range tests {
+		if got := arnAccountID(tt.arn); got != tt.want {
+			t.Errorf("arnAccountID(%q) = %q, want %q", tt.arn, got, tt.want)
+		}
+	}
+}
@@ -4,49 +4,114 @@ import (
 	"context"
 	"fmt"
 	"log"
+	"sort"
+	"strings"
 	"time"
 
 	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/cloudtrail"
+	"github.com/aws/aws-sdk-go-v2/service/cloudwatch"
 	"github.com/aws/aws-sdk-go-v2/service/ec2"
 	ec2types "github.com/aws/aws-sdk-go-v2/service/ec2/types"
 	"github.com/sirupsen/logrus"
+	"github.com/yourusername/cloudshaver/internal/inventory"
 	awspricing "github.com/yourusername/cloudshaver/internal/pricing/aws"
+	pricingclient "github.com/yourusername/cloudshaver/internal/pricing/client"
+	"github.com/yourusername/cloudshaver/internal/registry"
 	"github.com/yourusername/cloudshaver/internal/types"
 )
 
-// Instance type upgrade paths for cost optimization
-var instanceUpgrades = map[string]string{
-	"t2.micro":  "t3.micro",
-	"t2.small":  "t3.small",
-	"t2.medium": "t3.medium",
-	"m4.large":  "m5.large",
-	"m4.xlarge": "m5.xlarge",
-	"c4.large":  "c5.large",
-	"c4.xlarge": "c5.xlarge",
+func init() {
+	registry.Register(registry.Registration{
+		Name:        "ec2-optimization",
+		Description: "EC2 right-sizing, generation upgrades, Spot opportunities, stopped instances, unattached EBS volumes, EBS volume type migrations and over-provisioned IOPS",
+		Provider:    types.AWS,
+		Category:    types.ComputeOptimization,
+		Services:    []string{"ec2", "cloudwatch", "cloudtrail", "pricing"},
+		New: func(ctx context.Context, env registry.Env) (types.Blade, error) {
+			return NewEC2Blade(ctx, env.EC2Inventory, EC2BladeOptions{
+				CloudWatch:       cloudwatch.NewFromConfig(env.AWSConfig),
+				SpotPriceHistory: ec2.NewFromConfig(env.AWSConfig),
+				CloudTrail:       cloudtrail.NewFromConfig(env.AWSConfig),
+				MetricsLookback:  env.MetricsLookback,
+				IdleAge:          env.IdleAge,
+				AccountID:        env.Account.ID,
+				PricingSource:    env.PricingSource,
+				Pricing:          env.Pricing,
+			})
+		},
+	})
 }
 
-// Volume type upgrade paths for cost optimization
-var volumeUpgrades = map[string]string{
-	"gp2": "gp3",
-	"io1": "io2",
+// hoursPerMonth is the average number of hours in a month used for cost projections
+const hoursPerMonth = 730
+
+// EC2BladeOptions configures the optional analyses of the EC2 blade
+type EC2BladeOptions struct {
+	// CloudWatch enables utilization-based right-sizing when set
+	CloudWatch CloudWatchAPI
+	// SpotPriceHistory enables the Spot analysis of interruptible
+	// workloads when set
+	SpotPriceHistory SpotPriceHistoryAPI
+	// CloudTrail dates stopped instances and unattached volumes from their
+	// StopInstances and DetachVolume events when EC2 does not report it;
+	// without it unattached volumes are not reported
+	CloudTrail CloudTrailAPI
+	// MetricsLookback is the window of utilization metrics examined;
+	// DefaultMetricsLookback is used when zero
+	MetricsLookback time.Duration
+	// IdleAge is how long an instance must have been stopped, or a volume
+	// unattached, to be reported; the minimum_days of the static pricing
+	// data are used when zero
+	IdleAge time.Duration
+	// AccountID is the scanned account, which owns the volumes; it is empty
+	// when scanning with the ambient credentials
+	AccountID string
+	// PricingSource supplies price lists; nil downloads them with the
+	// default disk cache
+	PricingSource pricingclient.Source
+	// Pricing prices resources instead of the offer files of PricingSource
+	// when set, e.g. with fake prices in tests
+	Pricing awspricing.PriceProvider
 }
 
 type EC2Blade struct {
-	ec2Client      *ec2.Client
-	pricingService *awspricing.EC2PricingService
-	region         string
+	inventory        *inventory.EC2Inventory
+	pricingService   awspricing.PriceProvider
+	cloudWatch       CloudWatchAPI
+	spotPriceHistory SpotPriceHistoryAPI
+	cloudTrail       CloudTrailAPI
+	metricsLookback  time.Duration
+	idleAge          time.Duration
+	accountID        string
+	region           string
 }
 
-func NewEC2Blade(ec2Client *ec2.Client, region string) (*EC2Blade, error) {
-	pricingService, err := awspricing.NewEC2PricingService(region)
-	if err != nil {
-		return nil, fmt.Errorf("failed to create pricing service: %w", err)
+func NewEC2Blade(ctx context.Context, inv *inventory.EC2Inventory, opts EC2BladeOptions) (*EC2Blade, error) {
+	pricingService := opts.Pricing
+	if pricingService == nil {
+		service, err := awspricing.NewEC2PricingService(ctx, opts.PricingSource)
+		if err != nil {
+			return nil, fmt.Errorf("failed to create pricing service: %w", err)
+		}
+		pricingService = service
+	}
+
+	lookback := opts.MetricsLookback
+	if lookback <= 0 {
+		lookback = DefaultMetricsLookback
 	}
 
 	return &EC2Blade{
-		ec2Client:      ec2Client,
-		pricingService: pricingService,
-		region:         region,
+		inventory:        inv,
+		pricingService:   pricingService,
+		cloudWatch:       opts.CloudWatch,
+		spotPriceHistory: opts.SpotPriceHistory,
+		cloudTrail:       opts.CloudTrail,
+		metricsLookback:  lookback,
+		idleAge:          opts.IdleAge,
+		accountID:        opts.AccountID,
+		region:           inv.Region(),
 	}, nil
 }
 
@@ -58,7 +123,7 @@ func (b *EC2Blade) GetCategory() string {
 	return string(types.ComputeOptimization)
 }
 
-func (b *EC2Blade) Execute() (*types.BladeResult, error) {
+func (b *EC2Blade) Execute(ctx context.Context) (*types.BladeResult, error) {
 	// Collect all optimization results
 	result := &types.BladeResult{
 		CloudProvider:    string(types.AWS),
@@ -67,215 +132,441 @@ func (b *EC2Blade) Execute() (*types.BladeResult, error) {
 		PotentialSavings: 0,
 		Recommendations:  []string{},
 		Details:          make(map[string]string),
+		Findings:         []types.Finding{},
 		Timestamp:        time.Now(),
 	}
-
-	// Get all EBS volumes
-	volumes, err := b.ec2Client.DescribeVolumes(context.TODO(), &ec2.DescribeVolumesInput{})
-	if err != nil {
-		return nil, fmt.Errorf("failed to describe volumes: %w", err)
+	// Right-sizing, upgrading and moving to Spot are alternative actions for
+	// the same instance, so they are collected first and only the best one
+	// per instance is added to the result
+	var instanceActions []types.Finding
+	collectAction := func(analyze func(context.Context) ([]types.Finding, error)) func(context.Context) ([]types.Finding, error) {
+		return func(ctx context.Context) ([]types.Finding, error) {
+			findings, err := analyze(ctx)
+			instanceActions = append(instanceActions, findings...)
+			return nil, err
+		}
 	}
-
-	// Check for underutilized instances
-	underutilizedSavings, underutilizedRecs, err := b.analyzeUnderutilizedInstances()
-	if err != nil {
-		logrus.WithError(err).Error("Failed to analyze underutilized instances")
-	} else {
-		result.PotentialSavings += underutilizedSavings
-		result.Recommendations = append(result.Recommendations, underutilizedRecs...)
+	// Volumes right-sized from their metrics are left out of the type
+	// migrations, so a volume is reported once
+	rightsizedVolumes := make(map[string]bool)
+	err := runAnalyses(ctx, b.GetName(), result, []bladeAnalysis{
+		{name: "underutilized instances", run: collectAction(b.analyzeUnderutilizedInstances)},
+		{name: "generation upgrades", run: collectAction(b.analyzeGenerationUpgrades)},
+		{name: "spot opportunities", run: collectAction(b.analyzeSpotOpportunities)},
+		{name: "stopped instances", run: b.analyzeStoppedInstances},
+		{name: "unattached volumes", run: b.analyzeUnattachedVolumes},
+		{name: "overprovisioned volumes", run: func(ctx context.Context) ([]types.Finding, error) {
+			return b.analyzeOverprovisionedVolumes(ctx, rightsizedVolumes)
+		}},
+		{name: "volume migrations", run: func(ctx context.Context) ([]types.Finding, error) {
+			return b.analyzeVolumeMigrations(ctx, rightsizedVolumes)
+		}},
+	})
+	for _, finding := range bestInstanceActions(instanceActions) {
+		result.AddFinding(finding)
 	}
 
-	// Check for stopped instances
-	stoppedSavings, stoppedRecs, err := b.analyzeStoppedInstances()
-	if err != nil {
-		logrus.WithError(err).Error("Failed to analyze stopped instances")
-	} else {
-		result.PotentialSavings += stoppedSavings
-		result.Recommendations = append(result.Recommendations, stoppedRecs...)
+	summarizeFindings(result)
+	return result, err
+}
+
+// bestInstanceActions keeps the action with the highest savings for each
+// instance. The other actions are listed in its details without counting
+// their savings, since only one of them can be taken.
+func bestInstanceActions(actions []types.Finding) []types.Finding {
+	index := make(map[string]int, len(actions))
+	var best []types.Finding
+	var alternatives [][]types.Finding
+	for _, action := range actions {
+		i, ok := index[action.ResourceID]
+		if !ok {
+			index[action.ResourceID] = len(best)
+			best = append(best, action)
+			alternatives = append(alternatives, nil)
+			continue
+		}
+		if action.Savings > best[i].Savings {
+			best[i], action = action, best[i]
+		}
+		alternatives[i] = append(alternatives[i], action)
 	}
 
-	// Check for unattached volumes
-	volumeSavings, volumeRecs, err := b.analyzeUnattachedVolumes(context.TODO(), volumes.Volumes)
-	if err != nil {
-		logrus.WithError(err).Error("Failed to analyze unattached volumes")
-	} else {
-		result.PotentialSavings += volumeSavings
-		result.Recommendations = append(result.Recommendations, volumeRecs...)
+	for i := range best {
+		if len(alternatives[i]) == 0 {
+			continue
+		}
+		details := make(map[string]string, len(best[i].Details)+len(alternatives[i]))
+		for key, value := range best[i].Details {
+			details[key] = value
+		}
+		for _, alternative := range alternatives[i] {
+			details["alternative_action:"+string(alternative.Kind)] = fmt.Sprintf("%s ($%.2f per month, not counted)",
+				alternative.Recommendation, alternative.Savings)
+		}
+		best[i].Details = details
 	}
+	return best
+}
 
-	return result, nil
+// upgradeOption is an upgrade candidate priced for an instance's platform
+type upgradeOption struct {
+	awspricing.UpgradeCandidate
+	pricePerHour float64
 }
 
-func (b *EC2Blade) analyzeUnderutilizedInstances() (float64, []string, error) {
-	describeInput := &ec2.DescribeInstancesInput{
-		Filters: []ec2types.Filter{
-			{
-				Name:   aws.String("instance-state-name"),
-				Values: []string{"running"},
-			},
-		},
+// analyzeGenerationUpgrades recommends the cheapest same-architecture
+// replacement from the instance catalog for every running instance, listing
+// Graviton and AMD alternatives in the details. Graviton is only recommended
+// outright when it is the sole cheaper option, since it needs arm64 builds.
+// Instances running licensed platforms are priced with their license.
+func (b *EC2Blade) analyzeGenerationUpgrades(ctx context.Context) ([]types.Finding, error) {
+	instances, err := b.inventory.InstancesInState(ctx, ec2types.InstanceStateNameRunning)
+	if err != nil {
+		return nil, err
+	}
+	if len(instances) == 0 || !b.pricingService.IsRegionSupported(b.region) {
+		return nil, nil
 	}
 
-	instancesOutput, err := b.ec2Client.DescribeInstances(context.TODO(), describeInput)
+	catalog, err := b.pricingService.InstanceCatalog(ctx, b.region)
 	if err != nil {
-		return 0, nil, err
+		return nil, err
 	}
 
-	var totalSavings float64
-	var recommendations []string
-	instanceSavings := make(map[string]float64)
-	instanceRecommendations := make(map[string]string)
-
-	for _, reservation := range instancesOutput.Reservations {
-		for _, instance := range reservation.Instances {
-			instanceType := string(instance.InstanceType)
-			instanceID := *instance.InstanceId
-
-			// Check for instance type upgrade opportunities
-			if targetType, ok := instanceUpgrades[instanceType]; ok {
-				savings, err := b.pricingService.CalculateInstanceSavings(instanceType, targetType, b.region)
-				if err != nil {
-					logrus.WithError(err).Errorf("Failed to calculate savings for instance %s", instanceID)
-					continue
-				}
+	var findings []types.Finding
 
-				if savings > 0 {
-					instanceSavings[instanceID] = savings
-					instanceRecommendations[instanceID] = fmt.Sprintf("Upgrade from %s to %s", instanceType, targetType)
-					totalSavings += savings
-				}
+	for _, instance := range instances {
+		instanceType := string(instance.InstanceType)
+		instanceID := aws.ToString(instance.InstanceId)
+		platform := instancePlatform(instance)
+
+		current, ok := catalog.Lookup(instanceType)
+		if !ok {
+			logrus.Debugf("Instance type %s of %s not found in the %s catalog", instanceType, instanceID, b.region)
+			continue
+		}
+
+		candidates := catalog.UpgradeCandidates(instanceType)
+		if len(candidates) == 0 {
+			continue
+		}
+
+		currentPrice, options, err := b.priceUpgradeOptions(ctx, current, candidates, platform)
+		if err != nil {
+			if ctx.Err() != nil {
+				return findings, ctx.Err()
+			}
+			logrus.WithError(err).Errorf("Failed to get price for instance %s", instanceID)
+			continue
+		}
+		if len(options) == 0 {
+			continue
+		}
+
+		// Prefer a target that runs the same binaries over the cheapest one
+		target := options[0]
+		confidence := types.ConfidenceHigh
+		for _, option := range options {
+			if option.Reason != awspricing.UpgradeGraviton {
+				target = option
+				break
+			}
+		}
+		if target.Reason == awspricing.UpgradeGraviton {
+			confidence = types.ConfidenceLow
+		}
+
+		details := map[string]string{
+			"current_type":      instanceType,
+			"target_type":       target.Spec.InstanceType,
+			"upgrade_reason":    string(target.Reason),
+			"platform":          platform.Name,
+			"current_processor": string(current.Processor),
+			"target_processor":  string(target.Spec.Processor),
+			"vcpu":              fmt.Sprintf("%d -> %d", current.VCPU, target.Spec.VCPU),
+			"memory_gib":        fmt.Sprintf("%g -> %g", current.MemoryGiB, target.Spec.MemoryGiB),
+		}
+		for _, option := range options {
+			if option.Spec.InstanceType == target.Spec.InstanceType {
+				continue
 			}
+			details["alternative:"+string(option.Reason)] = fmt.Sprintf("%s, $%.2f per month",
+				option.Spec.InstanceType, option.pricePerHour*hoursPerMonth)
 		}
+
+		currentCost := currentPrice * hoursPerMonth
+		projectedCost := target.pricePerHour * hoursPerMonth
+		findings = append(findings, types.Finding{
+			ResourceID:     instanceID,
+			ResourceARN:    ec2ARN(b.region, instance.OwnerID, "instance", instanceID),
+			ResourceType:   "EC2 Instance",
+			Region:         b.region,
+			AccountID:      instance.OwnerID,
+			Kind:           types.FindingGenerationUpgrade,
+			Recommendation: fmt.Sprintf("Upgrade from %s to %s", instanceType, target.Spec.InstanceType),
+			CurrentCost:    currentCost,
+			ProjectedCost:  projectedCost,
+			Savings:        currentCost - projectedCost,
+			Confidence:     confidence,
+			Details:        details,
+		})
 	}
 
-	// Generate recommendations
-	if len(instanceSavings) > 0 {
-		recommendations = append(recommendations,
-			fmt.Sprintf("Found %d instances with optimization opportunities:", len(instanceSavings)))
+	return findings, nil
+}
 
-		for instanceID, savings := range instanceSavings {
-			recommendations = append(recommendations,
-				fmt.Sprintf("Instance %s: %s (Monthly savings: $%.2f)",
-					instanceID, instanceRecommendations[instanceID], savings))
+// priceUpgradeOptions prices the current type and the upgrade candidates for
+// platform, keeping the candidates that are offered for it and still cheaper.
+// The catalog holds Linux prices, so other platforms are looked up per type.
+func (b *EC2Blade) priceUpgradeOptions(ctx context.Context, current awspricing.InstanceSpec, candidates []awspricing.UpgradeCandidate, platform awspricing.Platform) (float64, []upgradeOption, error) {
+	currentPrice := current.PricePerHour
+	if !platform.IsLinux() {
+		var err error
+		currentPrice, err = b.pricingService.GetInstancePrice(ctx, current.InstanceType, b.region, platform)
+		if err != nil {
+			return 0, nil, err
+		}
+	}
+
+	var options []upgradeOption
+	for _, candidate := range candidates {
+		price := candidate.Spec.PricePerHour
+		if !platform.IsLinux() {
+			var err error
+			price, err = b.pricingService.GetInstancePrice(ctx, candidate.Spec.InstanceType, b.region, platform)
+			if err != nil {
+				if ctx.Err() != nil {
+					return 0, nil, ctx.Err()
+				}
+				// Graviton types are not offered for Windows, for example
+				logrus.WithError(err).Debugf("Skipping upgrade candidate %s", candidate.Spec.InstanceType)
+				continue
+			}
+		}
+		if price < currentPrice {
+			options = append(options, upgradeOption{UpgradeCandidate: candidate, pricePerHour: price})
 		}
 	}
 
-	return totalSavings, recommendations, nil
+	sort.SliceStable(options, func(i, j int) bool {
+		return options[i].pricePerHour < options[j].pricePerHour
+	})
+	return currentPrice, options, nil
 }
 
-func (b *EC2Blade) analyzeStoppedInstances() (float64, []string, error) {
-	describeInput := &ec2.DescribeInstancesInput{
-		Filters: []ec2types.Filter{
-			{
-				Name:   aws.String("instance-state-name"),
-				Values: []string{"stopped"},
-			},
-		},
+// analyzeStoppedInstances reports instances stopped for longer than the
+// stopped threshold, which still pay for their EBS volumes
+func (b *EC2Blade) analyzeStoppedInstances(ctx context.Context) ([]types.Finding, error) {
+	instances, err := b.inventory.InstancesInState(ctx, ec2types.InstanceStateNameStopped)
+	if err != nil {
+		return nil, err
 	}
 
-	instancesOutput, err := b.ec2Client.DescribeInstances(context.TODO(), describeInput)
+	// Get all volumes grouped by the instance they are attached to
+	volumesByInstance, err := b.inventory.VolumesByInstance(ctx)
 	if err != nil {
-		return 0, nil, err
+		return nil, err
 	}
 
-	var stoppedInstances []string
-	var potentialSavings float64
-	var volumeDetails []string
-
-	for _, reservation := range instancesOutput.Reservations {
-		for _, instance := range reservation.Instances {
-			instanceID := *instance.InstanceId
-
-			// Get all volumes attached to this instance
-			volumeInput := &ec2.DescribeVolumesInput{
-				Filters: []ec2types.Filter{
-					{
-						Name:   aws.String("attachment.instance-id"),
-						Values: []string{instanceID},
-					},
-				},
-			}
+	thresholds := loadIdleThresholds(b.idleAge)
+	now := time.Now().UTC()
 
-			volumesOutput, err := b.ec2Client.DescribeVolumes(context.TODO(), volumeInput)
-			if err != nil {
-				log.Printf("Failed to get volumes for instance %s: %v", instanceID, err)
-				continue
-			}
+	var findings []types.Finding
 
-			var instanceVolumeCost float64
-			for _, volume := range volumesOutput.Volumes {
-				if !b.pricingService.IsRegionSupported(b.region) {
-					log.Printf("Region %s not supported for pricing calculations", b.region)
-					continue
-				}
+	for _, instance := range instances {
+		instanceID := aws.ToString(instance.InstanceId)
 
-				price, err := b.pricingService.GetVolumePrice(string(volume.VolumeType), b.region)
-				if err != nil {
-					log.Printf("Failed to get price for volume %s: %v", *volume.VolumeId, err)
-					continue
-				}
+		stopped, ok, err := b.instanceStoppedSince(ctx, instance, now)
+		if err != nil {
+			return findings, err
+		}
+		if !ok {
+			logrus.Debugf("Skipping stopped instance %s with an unknown stop time", instanceID)
+			continue
+		}
+		idleDays := stopped.days(now)
+		if idleDays < thresholds.stoppedDays {
+			continue
+		}
 
-				// Calculate monthly cost: price per GB-month * size
-				monthlyCost := price * float64(*volume.Size)
-				instanceVolumeCost += monthlyCost
+		var instanceVolumeCost float64
+		details := map[string]string{
+			"instance_type":     string(instance.InstanceType),
+			"idle_since":        stopped.since.Format(time.RFC3339),
+			"idle_days":         fmt.Sprintf("%d", idleDays),
+			"idle_since_source": stopped.source,
+		}
+		for _, volume := range volumesByInstance[instanceID] {
+			if !b.pricingService.IsRegionSupported(b.region) {
+				log.Printf("Region %s not supported for pricing calculations", b.region)
+				continue
+			}
 
-				volumeDetails = append(volumeDetails,
-					fmt.Sprintf("Instance %s: %s volume of size %d GB costing $%.2f per month",
-						instanceID, volume.VolumeType, *volume.Size, monthlyCost))
+			cost, err := awspricing.GetVolumeCost(ctx, b.pricingService, volumeSpec(volume), b.region)
+			if err != nil {
+				if ctx.Err() != nil {
+					return findings, ctx.Err()
+				}
+				log.Printf("Failed to get price for volume %s: %v", aws.ToString(volume.VolumeId), err)
+				continue
 			}
 
-			stoppedInstances = append(stoppedInstances, instanceID)
-			potentialSavings += instanceVolumeCost
+			monthlyCost := cost.Total()
+			instanceVolumeCost += monthlyCost
+
+			details["volume:"+aws.ToString(volume.VolumeId)] = fmt.Sprintf("%s, %d GB, $%.2f per month",
+				volume.VolumeType, aws.ToInt32(volume.Size), monthlyCost)
 		}
-	}
 
-	var recommendations []string
-	if len(stoppedInstances) > 0 {
-		recommendations = append(recommendations,
-			fmt.Sprintf("Found %d stopped instances that are still incurring EBS costs:", len(stoppedInstances)))
-		recommendations = append(recommendations, volumeDetails...)
-		recommendations = append(recommendations,
-			fmt.Sprintf("Total potential monthly savings: $%.2f", potentialSavings),
-			"Consider taking these actions:",
-			"- Terminate instances that have been stopped for extended periods",
-			"- Create snapshots of important volumes before termination",
-			"- Implement automated cleanup of stopped instances after defined period",
-			"- Use automated snapshots to recreate volumes when needed")
+		findings = append(findings, types.Finding{
+			ResourceID:   instanceID,
+			ResourceARN:  ec2ARN(b.region, instance.OwnerID, "instance", instanceID),
+			ResourceType: "EC2 Instance",
+			Region:       b.region,
+			AccountID:    instance.OwnerID,
+			Kind:         types.FindingStoppedInstance,
+			Recommendation: fmt.Sprintf("Instance stopped for %d days still incurring EBS costs; "+
+				"snapshot important volumes and terminate it if it is no longer needed", idleDays),
+			CurrentCost:   instanceVolumeCost,
+			ProjectedCost: 0,
+			Savings:       instanceVolumeCost,
+			Confidence:    types.ConfidenceMedium,
+			Details:       details,
+		})
 	}
 
-	return potentialSavings, recommendations, nil
+	return findings, nil
 }
 
-func (b *EC2Blade) analyzeUnattachedVolumes(ctx context.Context, volumes []ec2types.Volume) (float64, []string, error) {
-	var potentialSavings float64
-	var recommendations []string
+// analyzeUnattachedVolumes reports volumes left unattached for longer than
+// the unattached threshold
+func (b *EC2Blade) analyzeUnattachedVolumes(ctx context.Context) ([]types.Finding, error) {
+	// Get all EBS volumes
+	volumes, err := b.inventory.Volumes(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	thresholds := loadIdleThresholds(b.idleAge)
+	now := time.Now().UTC()
+
+	var findings []types.Finding
+	var unknownDetach int
 
 	for _, volume := range volumes {
 		if volume.State != ec2types.VolumeStateAvailable {
 			continue
 		}
 
+		// A volume younger than the threshold cannot have been idle for
+		// longer, which spares the CloudTrail lookup
+		volumeID := aws.ToString(volume.VolumeId)
+		if created := aws.ToTime(volume.CreateTime); now.Sub(created) < time.Duration(thresholds.unattachedDays)*24*time.Hour {
+			continue
+		}
+		detached, ok, err := b.volumeDetachedSince(ctx, volume, now)
+		if err != nil {
+			return findings, err
+		}
+
+		finding := types.Finding{
+			ResourceID:   volumeID,
+			ResourceARN:  ec2ARN(b.region, b.accountID, "volume", volumeID),
+			ResourceType: "EBS Volume",
+			Region:       b.region,
+			AccountID:    b.accountID,
+			Kind:         types.FindingUnattachedVolume,
+			Confidence:   types.ConfidenceHigh,
+			Details: map[string]string{
+				"volume_type": string(volume.VolumeType),
+				"size_gb":     fmt.Sprintf("%d", aws.ToInt32(volume.Size)),
+			},
+		}
+		if ok {
+			idleDays := detached.days(now)
+			if idleDays < thresholds.unattachedDays {
+				continue
+			}
+			finding.Recommendation = fmt.Sprintf("Delete %s volume of size %d GB unattached for %d days",
+				volume.VolumeType, aws.ToInt32(volume.Size), idleDays)
+			finding.Details["idle_since"] = detached.since.Format(time.RFC3339)
+			finding.Details["idle_days"] = fmt.Sprintf("%d", idleDays)
+			finding.Details["idle_since_source"] = detached.source
+		} else {
+			// The volume may have been detached at any time since it was
+			// created, so it may not have been idle for the threshold yet
+			unknownDetach++
+			finding.Recommendation = fmt.Sprintf("Delete %s volume of size %d GB unattached since an unknown time, after checking it is no longer needed",
+				volume.VolumeType, aws.ToInt32(volume.Size))
+			finding.Confidence = types.ConfidenceLow
+			finding.Details["idle_since"] = "unknown"
+		}
+
 		if !b.pricingService.IsRegionSupported(b.region) {
-			recommendations = append(recommendations,
-				fmt.Sprintf("Unattached volume %s in region %s (pricing not available)",
-					aws.ToString(volume.VolumeId), b.region))
+			finding.Confidence = types.ConfidenceLow
+			finding.Details["pricing"] = "not available"
+			findings = append(findings, finding)
 			continue
 		}
 
-		price, err := b.pricingService.GetVolumePrice(string(volume.VolumeType), b.region)
+		cost, err := awspricing.GetVolumeCost(ctx, b.pricingService, volumeSpec(volume), b.region)
 		if err != nil {
+			if ctx.Err() != nil {
+				return findings, ctx.Err()
+			}
 			// Log error but continue with analysis
-			log.Printf("Failed to get price for volume %s: %v", aws.ToString(volume.VolumeId), err)
+			log.Printf("Failed to get price for volume %s: %v", volumeID, err)
 			continue
 		}
 
-		monthlyCost := price * float64(*volume.Size) * 24 * 30 // Monthly cost
-		potentialSavings += monthlyCost
+		if cost.IOPS > 0 {
+			finding.Details["provisioned_iops"] = fmt.Sprintf("%d", aws.ToInt32(volume.Iops))
+		}
+		if cost.Throughput > 0 {
+			finding.Details["throughput_mibps"] = fmt.Sprintf("%d", aws.ToInt32(volume.Throughput))
+		}
+		finding.CurrentCost = cost.Total()
+		finding.Savings = cost.Total()
+		findings = append(findings, finding)
+	}
+
+	if unknownDetach > 0 {
+		logrus.Warnf("Reporting %d unattached volumes in %s with low confidence, as their detach time is unknown",
+			unknownDetach, b.region)
+	}
+	return findings, nil
+}
+
+// instancePlatform returns the operating system and license an instance is
+// billed for
+func instancePlatform(instance inventory.Instance) awspricing.Platform {
+	return awspricing.InstancePlatform(aws.ToString(instance.PlatformDetails), aws.ToString(instance.UsageOperation))
+}
 
-		recommendations = append(recommendations,
-			fmt.Sprintf("Unattached %s volume %s of size %d GB costing approximately $%.2f per month",
-				volume.VolumeType, aws.ToString(volume.VolumeId), *volume.Size, monthlyCost))
+// volumeSpec returns the provisioned configuration a volume is billed for
+func volumeSpec(volume ec2types.Volume) awspricing.VolumeSpec {
+	return awspricing.VolumeSpec{
+		VolumeType: string(volume.VolumeType),
+		SizeGB:     int(aws.ToInt32(volume.Size)),
+		IOPS:       int(aws.ToInt32(volume.Iops)),
+		Throughput: int(aws.ToInt32(volume.Throughput)),
 	}
+}
 
-	return potentialSavings, recommendations, nil
+// ec2ARN builds the ARN of an EC2 resource, or returns an empty string when
+// the owning account is unknown
+func ec2ARN(region, accountID, resourceType, resourceID string) string {
+	if accountID == "" || resourceID == "" {
+		return ""
+	}
+	return fmt.Sprintf("arn:aws:ec2:%s:%s:%s/%s", region, accountID, resourceType, resourceID)
+}
+
+// arnAccountID returns the account ID field of an ARN, or "" if it has none
+func arnAccountID(arn string) string {
+	fields := strings.SplitN(arn, ":", 6)
+	if len(fields) < 6 || fields[0] != "arn" {
+		return ""
+	}
+	return fields[4]
 }
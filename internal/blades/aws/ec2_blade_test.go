@@ -0,0 +1,53 @@
+package awsblades
+
+import (
+	"testing"
+
+	"github.com/yourusername/cloudshaver/internal/types"
+)
+
+func TestBestInstanceActions(t *testing.T) {
+	actions := []types.Finding{
+		{ResourceID: "i-1", Kind: types.FindingRightsize, Recommendation: "Downsize", Savings: 20},
+		{ResourceID: "i-1", Kind: types.FindingGenerationUpgrade, Recommendation: "Upgrade", Savings: 5, Details: map[string]string{"target_type": "m5.large"}},
+		{ResourceID: "i-2", Kind: types.FindingGenerationUpgrade, Recommendation: "Upgrade", Savings: 5},
+		{ResourceID: "i-1", Kind: types.FindingSpot, Recommendation: "Run on Spot", Savings: 30},
+	}
+
+	best := bestInstanceActions(actions)
+	if len(best) != 2 {
+		t.Fatalf("%d findings, want one per instance", len(best))
+	}
+
+	var total float64
+	for _, finding := range best {
+		total += finding.Savings
+	}
+	if total != 35 {
+		t.Errorf("savings = %v, want 35", total)
+	}
+
+	first := best[0]
+	if first.ResourceID != "i-1" || first.Kind != types.FindingSpot {
+		t.Errorf("best action of i-1 = %s, want %s", first.Kind, types.FindingSpot)
+	}
+	want := map[string]string{
+		"alternative_action:rightsize":          "Downsize ($20.00 per month, not counted)",
+		"alternative_action:generation-upgrade": "Upgrade ($5.00 per month, not counted)",
+	}
+	for key, value := range want {
+		if first.Details[key] != value {
+			t.Errorf("%s = %q, want %q", key, first.Details[key], value)
+		}
+	}
+	if _, ok := first.Details["target_type"]; ok {
+		t.Error("details of an alternative leaked into the best action")
+	}
+
+	if second := best[1]; second.ResourceID != "i-2" || len(second.Details) != 0 {
+		t.Errorf("i-2 = %+v, want its only action unchanged", second)
+	}
+	if len(actions[1].Details) != 1 {
+		t.Error("bestInstanceActions modified the details of its input")
+	}
+}
@@ -0,0 +1,159 @@
+package awsblades
+
+import (
+	"context"
+	"regexp"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	ec2types "github.com/aws/aws-sdk-go-v2/service/ec2/types"
+	"github.com/sirupsen/logrus"
+	"github.com/yourusername/cloudshaver/internal/inventory"
+	awspricing "github.com/yourusername/cloudshaver/internal/pricing/aws"
+)
+
+// Sources of the time a resource became idle, reported in the findings
+const (
+	// idleSourceStateTransition is the time in the state transition reason
+	// of a stopped instance
+	idleSourceStateTransition = "state-transition-reason"
+	// idleSourceCloudTrail is the time of the StopInstances or DetachVolume
+	// event
+	idleSourceCloudTrail = "cloudtrail"
+	// idleSourceCloudTrailLookback is the start of the CloudTrail history,
+	// which holds no event making the resource idle, so it was idle before
+	idleSourceCloudTrailLookback = "cloudtrail-lookback"
+	// idleSourceCreation is the creation time of a volume never attached
+	// within the CloudTrail history
+	idleSourceCreation = "creation"
+)
+
+// stateTransitionTime matches the time EC2 appends to the state transition
+// reason, e.g. "User initiated (2024-01-15 10:30:45 GMT)"
+var stateTransitionTime = regexp.MustCompile(`\((\d{4}-\d{2}-\d{2} \d{2}:\d{2}:\d{2}) GMT\)`)
+
+// idleThresholds are the minimum idle ages of reported resources
+type idleThresholds struct {
+	stoppedDays    int
+	unattachedDays int
+}
+
+// idlePeriod is when a resource became idle and how that is known
+type idlePeriod struct {
+	since  time.Time
+	source string
+}
+
+// days returns the whole days the resource has been idle at now
+func (p idlePeriod) days(now time.Time) int {
+	return int(now.Sub(p.since).Hours() / 24)
+}
+
+// loadIdleThresholds returns the stopped instance and unattached volume
+// thresholds: idleAge for both when set, else the thresholds of the static
+// pricing data, falling back to its documented defaults
+func loadIdleThresholds(idleAge time.Duration) idleThresholds {
+	if days := int(idleAge.Hours() / 24); days > 0 {
+		return idleThresholds{stoppedDays: days, unattachedDays: days}
+	}
+
+	thresholds := idleThresholds{stoppedDays: 7, unattachedDays: 7}
+
+	pricing, err := awspricing.LoadPricing()
+	if err != nil {
+		logrus.WithError(err).Warn("Failed to load idle resource thresholds, using defaults")
+		return thresholds
+	}
+
+	opportunities := pricing.SavingsOpportunities
+	if days := opportunities.InstanceUpgrade.Stopped.MinimumDays; days > 0 {
+		thresholds.stoppedDays = days
+	}
+	if days := opportunities.VolumeOptimization.Unattached.MinimumDays; days > 0 {
+		thresholds.unattachedDays = days
+	}
+	return thresholds
+}
+
+// parseStateTransitionTime returns the time in a state transition reason
+func parseStateTransitionTime(reason string) (time.Time, bool) {
+	match := stateTransitionTime.FindStringSubmatch(reason)
+	if match == nil {
+		return time.Time{}, false
+	}
+	t, err := time.Parse(time.DateTime, match[1])
+	if err != nil {
+		return time.Time{}, false
+	}
+	return t, true
+}
+
+// instanceStoppedSince returns when a stopped instance stopped, from its
+// state transition reason or else its last StopInstances event. Without
+// either the stop time is unknown, unless CloudTrail shows the instance did
+// not start within its history.
+func (b *EC2Blade) instanceStoppedSince(ctx context.Context, instance inventory.Instance, now time.Time) (idlePeriod, bool, error) {
+	if since, ok := parseStateTransitionTime(aws.ToString(instance.StateTransitionReason)); ok {
+		return idlePeriod{since: since, source: idleSourceStateTransition}, true, nil
+	}
+
+	start := now.Add(-cloudTrailLookback)
+	events, err := b.resourceEvents(ctx, aws.ToString(instance.InstanceId), start)
+	if err != nil || events == nil {
+		return idlePeriod{}, false, err
+	}
+	if stopped, ok := events["StopInstances"]; ok {
+		return idlePeriod{since: stopped, source: idleSourceCloudTrail}, true, nil
+	}
+	if instance.LaunchTime == nil || instance.LaunchTime.After(start) {
+		// Stopped within the history without a StopInstances call, e.g. by
+		// a shutdown from the instance
+		return idlePeriod{}, false, nil
+	}
+	return idlePeriod{since: start, source: idleSourceCloudTrailLookback}, true, nil
+}
+
+// volumeDetachedSince returns when an unattached volume was last detached,
+// from its last DetachVolume event. A volume with no attachment in the
+// CloudTrail history has been idle since its creation, or since the start
+// of the history. Otherwise, and without CloudTrail, the detach time is
+// unknown: the last attachment or the creation only bound it from above, so
+// dating the volume by them could report one detached yesterday as idle for
+// months.
+func (b *EC2Blade) volumeDetachedSince(ctx context.Context, volume ec2types.Volume, now time.Time) (idlePeriod, bool, error) {
+	start := now.Add(-cloudTrailLookback)
+	events, err := b.resourceEvents(ctx, aws.ToString(volume.VolumeId), start)
+	if err != nil || events == nil {
+		return idlePeriod{}, false, err
+	}
+
+	attached, wasAttached := events["AttachVolume"]
+	if detached, ok := events["DetachVolume"]; ok && !detached.Before(attached) {
+		return idlePeriod{since: detached, source: idleSourceCloudTrail}, true, nil
+	}
+	if wasAttached {
+		// Attached after the last recorded detach
+		return idlePeriod{}, false, nil
+	}
+	if created := aws.ToTime(volume.CreateTime); created.After(start) {
+		return idlePeriod{since: created, source: idleSourceCreation}, true, nil
+	}
+	return idlePeriod{since: start, source: idleSourceCloudTrailLookback}, true, nil
+}
+
+// resourceEvents returns the latest CloudTrail events of a resource since
+// start, or nil when CloudTrail is not configured or the lookup failed
+func (b *EC2Blade) resourceEvents(ctx context.Context, resourceID string, start time.Time) (map[string]time.Time, error) {
+	if b.cloudTrail == nil {
+		return nil, nil
+	}
+	events, err := latestEvents(ctx, b.cloudTrail, resourceID, start)
+	if err != nil {
+		if ctx.Err() != nil {
+			return nil, ctx.Err()
+		}
+		logrus.WithError(err).Warnf("Idle age of %s is unknown without CloudTrail", resourceID)
+		return nil, nil
+	}
+	return events, nil
+}
@@ -0,0 +1,321 @@
+package awsblades
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/cloudwatch"
+	cwtypes "github.com/aws/aws-sdk-go-v2/service/cloudwatch/types"
+	ec2types "github.com/aws/aws-sdk-go-v2/service/ec2/types"
+	"github.com/sirupsen/logrus"
+	"github.com/yourusername/cloudshaver/internal/inventory"
+	awspricing "github.com/yourusername/cloudshaver/internal/pricing/aws"
+	"github.com/yourusername/cloudshaver/internal/types"
+)
+
+// smallerSize maps an instance size to the size with half its capacity
+var smallerSize = map[string]string{
+	"micro":    "nano",
+	"small":    "micro",
+	"medium":   "small",
+	"large":    "medium",
+	"xlarge":   "large",
+	"2xlarge":  "xlarge",
+	"4xlarge":  "2xlarge",
+	"6xlarge":  "3xlarge",
+	"8xlarge":  "4xlarge",
+	"12xlarge": "6xlarge",
+	"16xlarge": "8xlarge",
+	"18xlarge": "9xlarge",
+	"24xlarge": "12xlarge",
+	"32xlarge": "16xlarge",
+	"48xlarge": "24xlarge",
+}
+
+// leanerFamily maps a family class to the class with half the memory per vCPU
+var leanerFamily = map[string]string{
+	"r": "m",
+	"m": "c",
+}
+
+// rightsizingThresholds are the p95 utilization limits below which a running
+// instance is considered oversized
+type rightsizingThresholds struct {
+	cpuPercent    float64
+	memoryPercent float64
+	minimumDays   int
+}
+
+// instanceUtilization is the observed utilization of one instance
+type instanceUtilization struct {
+	cpuP95    float64
+	memoryP95 float64
+	hasMemory bool
+}
+
+// loadRightsizingThresholds reads the oversized thresholds from the static
+// pricing data, falling back to its documented defaults
+func loadRightsizingThresholds() rightsizingThresholds {
+	thresholds := rightsizingThresholds{cpuPercent: 20, memoryPercent: 30, minimumDays: 14}
+
+	pricing, err := awspricing.LoadPricing()
+	if err != nil {
+		logrus.WithError(err).Warn("Failed to load right-sizing thresholds, using defaults")
+		return thresholds
+	}
+
+	oversized := pricing.SavingsOpportunities.InstanceUpgrade.Oversized
+	if oversized.CPUThresholdPercent > 0 {
+		thresholds.cpuPercent = float64(oversized.CPUThresholdPercent)
+	}
+	if oversized.MemoryThresholdPercent > 0 {
+		thresholds.memoryPercent = float64(oversized.MemoryThresholdPercent)
+	}
+	if oversized.MinimumDays > 0 {
+		thresholds.minimumDays = oversized.MinimumDays
+	}
+	return thresholds
+}
+
+// analyzeUnderutilizedInstances recommends smaller sizes or leaner families
+// for running instances whose p95 CPU, and memory when the CloudWatch agent
+// reports it, stayed under the oversized thresholds for the lookback window
+func (b *EC2Blade) analyzeUnderutilizedInstances(ctx context.Context) ([]types.Finding, error) {
+	if b.cloudWatch == nil {
+		return nil, nil
+	}
+
+	instances, err := b.inventory.InstancesInState(ctx, ec2types.InstanceStateNameRunning)
+	if err != nil {
+		return nil, err
+	}
+	if len(instances) == 0 {
+		return nil, nil
+	}
+
+	thresholds := loadRightsizingThresholds()
+	lookback := b.metricsLookback
+	if minimum := time.Duration(thresholds.minimumDays) * 24 * time.Hour; lookback < minimum {
+		lookback = minimum
+	}
+
+	utilization, err := b.fetchInstanceUtilization(ctx, instances, lookback, thresholds.minimumDays)
+	if err != nil {
+		return nil, err
+	}
+
+	var findings []types.Finding
+
+	for _, instance := range instances {
+		instanceID := aws.ToString(instance.InstanceId)
+		instanceType := string(instance.InstanceType)
+
+		usage, ok := utilization[instanceID]
+		if !ok || usage.cpuP95 >= thresholds.cpuPercent {
+			continue
+		}
+		if usage.hasMemory && usage.memoryP95 >= thresholds.memoryPercent {
+			continue
+		}
+
+		platform := instancePlatform(instance)
+		currentPrice, err := b.pricingService.GetInstancePrice(ctx, instanceType, b.region, platform)
+		if err != nil {
+			if ctx.Err() != nil {
+				return findings, ctx.Err()
+			}
+			logrus.WithError(err).Errorf("Failed to get price for instance %s", instanceID)
+			continue
+		}
+
+		targetType, targetPrice, err := b.cheapestRightsizeTarget(ctx, instanceType, platform, usage, thresholds)
+		if err != nil {
+			return findings, err
+		}
+		if targetType == "" || targetPrice >= currentPrice {
+			continue
+		}
+
+		confidence := types.ConfidenceHigh
+		memory := "not reported"
+		if usage.hasMemory {
+			memory = fmt.Sprintf("%.1f%%", usage.memoryP95)
+		} else {
+			// Without memory metrics a smaller size may not fit the workload
+			confidence = types.ConfidenceMedium
+		}
+
+		currentCost := currentPrice * hoursPerMonth
+		projectedCost := targetPrice * hoursPerMonth
+		findings = append(findings, types.Finding{
+			ResourceID:   instanceID,
+			ResourceARN:  ec2ARN(b.region, instance.OwnerID, "instance", instanceID),
+			ResourceType: "EC2 Instance",
+			Region:       b.region,
+			AccountID:    instance.OwnerID,
+			Kind:         types.FindingRightsize,
+			Recommendation: fmt.Sprintf("Downsize from %s to %s (p95 CPU %.1f%%, p95 memory %s over %d days)",
+				instanceType, targetType, usage.cpuP95, memory, int(lookback.Hours()/24)),
+			CurrentCost:   currentCost,
+			ProjectedCost: projectedCost,
+			Savings:       currentCost - projectedCost,
+			Confidence:    confidence,
+			Details: map[string]string{
+				"current_type":      instanceType,
+				"target_type":       targetType,
+				"platform":          platform.Name,
+				"cpu_p95_percent":   fmt.Sprintf("%.2f", usage.cpuP95),
+				"memory_p95":        memory,
+				"lookback_days":     fmt.Sprintf("%d", int(lookback.Hours()/24)),
+				"cpu_threshold":     fmt.Sprintf("%.0f%%", thresholds.cpuPercent),
+				"memory_threshold":  fmt.Sprintf("%.0f%%", thresholds.memoryPercent),
+				"metrics_namespace": "AWS/EC2, CWAgent",
+			},
+		})
+	}
+
+	return findings, nil
+}
+
+// fetchInstanceUtilization returns the p95 CPU and memory utilization of the
+// instances that have at least minimumDays of metric history
+func (b *EC2Blade) fetchInstanceUtilization(ctx context.Context, instances []inventory.Instance, lookback time.Duration, minimumDays int) (map[string]instanceUtilization, error) {
+	memoryDimensions, err := b.memoryMetricDimensions(ctx)
+	if err != nil {
+		if ctx.Err() != nil {
+			return nil, ctx.Err()
+		}
+		logrus.WithError(err).Warn("Failed to list CloudWatch agent memory metrics, right-sizing on CPU alone")
+	}
+
+	var queries []metricQuery
+	for i, instance := range instances {
+		instanceID := aws.ToString(instance.InstanceId)
+		queries = append(queries, metricQuery{
+			id:         fmt.Sprintf("cpu%d", i),
+			namespace:  "AWS/EC2",
+			metricName: "CPUUtilization",
+			dimensions: map[string]string{"InstanceId": instanceID},
+			stat:       "Average",
+		})
+		if dimensions, ok := memoryDimensions[instanceID]; ok {
+			queries = append(queries, metricQuery{
+				id:         fmt.Sprintf("mem%d", i),
+				namespace:  "CWAgent",
+				metricName: "mem_used_percent",
+				dimensions: dimensions,
+				stat:       "Average",
+			})
+		}
+	}
+
+	series, err := fetchMetricSeries(ctx, b.cloudWatch, queries, lookback)
+	if err != nil {
+		return nil, err
+	}
+
+	// Tolerate a few missing hourly samples in the required history
+	minimumSamples := minimumDays * 24 * 9 / 10
+
+	utilization := make(map[string]instanceUtilization)
+	for i, instance := range instances {
+		cpu := series[fmt.Sprintf("cpu%d", i)]
+		if len(cpu) < minimumSamples {
+			continue
+		}
+
+		usage := instanceUtilization{cpuP95: percentile(cpu, 95)}
+		if memory := series[fmt.Sprintf("mem%d", i)]; len(memory) >= minimumSamples {
+			usage.memoryP95 = percentile(memory, 95)
+			usage.hasMemory = true
+		}
+		utilization[aws.ToString(instance.InstanceId)] = usage
+	}
+
+	return utilization, nil
+}
+
+// memoryMetricDimensions returns the dimensions the CloudWatch agent
+// publishes mem_used_percent with for each instance. GetMetricData only
+// matches the exact dimensions of a metric, and the agent usually adds
+// ImageId, InstanceType or AutoScalingGroupName to InstanceId, so they are
+// listed rather than assumed. An instance published with several sets of
+// dimensions is queried with the smallest.
+func (b *EC2Blade) memoryMetricDimensions(ctx context.Context) (map[string]map[string]string, error) {
+	paginator := cloudwatch.NewListMetricsPaginator(b.cloudWatch, &cloudwatch.ListMetricsInput{
+		Namespace:  aws.String("CWAgent"),
+		MetricName: aws.String("mem_used_percent"),
+		Dimensions: []cwtypes.DimensionFilter{{Name: aws.String("InstanceId")}},
+	})
+
+	found := make(map[string]map[string]string)
+	for paginator.HasMorePages() {
+		page, err := paginator.NextPage(ctx)
+		if err != nil {
+			return nil, fmt.Errorf("failed to list memory metrics: %w", err)
+		}
+		for _, metric := range page.Metrics {
+			dimensions := make(map[string]string, len(metric.Dimensions))
+			for _, dimension := range metric.Dimensions {
+				dimensions[aws.ToString(dimension.Name)] = aws.ToString(dimension.Value)
+			}
+			instanceID := dimensions["InstanceId"]
+			if current, ok := found[instanceID]; !ok || len(dimensions) < len(current) {
+				found[instanceID] = dimensions
+			}
+		}
+	}
+	return found, nil
+}
+
+// cheapestRightsizeTarget walks down the sizes of the instance family while
+// the utilization at the current size is still under the oversized
+// thresholds, each step halving capacity and doubling utilization. The
+// leaner-family equivalent of the current size is added when memory use is
+// known to be low, and the candidate cheapest for the instance's platform is
+// returned.
+func (b *EC2Blade) cheapestRightsizeTarget(ctx context.Context, instanceType string, platform awspricing.Platform, usage instanceUtilization, thresholds rightsizingThresholds) (string, float64, error) {
+	family, size, ok := strings.Cut(instanceType, ".")
+	if !ok {
+		return "", 0, nil
+	}
+
+	var candidates []string
+	cpu, memory := usage.cpuP95, usage.memoryP95
+	for {
+		smaller, ok := smallerSize[size]
+		if !ok || cpu >= thresholds.cpuPercent || (usage.hasMemory && memory >= thresholds.memoryPercent) {
+			break
+		}
+		size, cpu, memory = smaller, cpu*2, memory*2
+		candidates = append(candidates, family+"."+size)
+	}
+
+	// A leaner family keeps the vCPUs but halves the memory, so it is only
+	// considered when memory use is known to be low
+	if lean, ok := leanerFamily[family[:1]]; ok && usage.hasMemory {
+		_, currentSize, _ := strings.Cut(instanceType, ".")
+		candidates = append(candidates, lean+family[1:]+"."+currentSize)
+	}
+
+	var bestType string
+	var bestPrice float64
+	for _, candidate := range candidates {
+		price, err := b.pricingService.GetInstancePrice(ctx, candidate, b.region, platform)
+		if err != nil {
+			if ctx.Err() != nil {
+				return "", 0, ctx.Err()
+			}
+			logrus.WithError(err).Debugf("No price for right-sizing candidate %s", candidate)
+			continue
+		}
+		if bestType == "" || price < bestPrice {
+			bestType, bestPrice = candidate, price
+		}
+	}
+
+	return bestType, bestPrice, nil
+}
@@ -0,0 +1,70 @@
+package awsblades
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	ec2types "github.com/aws/aws-sdk-go-v2/service/ec2/types"
+	"github.com/yourusername/cloudshaver/internal/types"
+)
+
+func TestAnalyzeUnderutilizedInstancesAgentDimensions(t *testing.T) {
+	// The CloudWatch agent publishes memory with the dimensions its default
+	// configuration appends
+	agentDimensions := map[string]string{
+		"InstanceId":   "i-1",
+		"ImageId":      "ami-1",
+		"InstanceType": "m5.large",
+	}
+
+	tests := []struct {
+		name       string
+		memory     float64
+		wantTarget string
+		wantMemory string
+	}{
+		// Low memory use allows the leaner c5 family
+		{name: "low memory", memory: 20, wantTarget: "c5.large", wantMemory: "20.0%"},
+		{name: "high memory", memory: 60},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			client := &fakeEC2{instances: []ec2types.Instance{runningInstance("i-1", "m5.large", time.Now().Add(-30*24*time.Hour))}}
+			cloudWatch := &fakeCloudWatch{
+				metrics: []fakeMetric{
+					{namespace: "AWS/EC2", name: "CPUUtilization", dimensions: map[string]string{"InstanceId": "i-1"}, value: 5},
+					{namespace: "CWAgent", name: "mem_used_percent", dimensions: agentDimensions, value: tt.memory},
+				},
+				samples: 20 * 24,
+			}
+			blade := newTestEC2Blade(t, client, EC2BladeOptions{CloudWatch: cloudWatch})
+
+			findings, err := blade.analyzeUnderutilizedInstances(context.Background())
+			if err != nil {
+				t.Fatalf("analyzeUnderutilizedInstances: %v", err)
+			}
+			if tt.wantTarget == "" {
+				if len(findings) != 0 {
+					t.Errorf("findings = %+v, want none", findings)
+				}
+				return
+			}
+			if len(findings) != 1 {
+				t.Fatalf("%d findings, want 1", len(findings))
+			}
+
+			finding := findings[0]
+			if got := finding.Details["target_type"]; got != tt.wantTarget {
+				t.Errorf("target = %s, want %s", got, tt.wantTarget)
+			}
+			if got := finding.Details["memory_p95"]; got != tt.wantMemory {
+				t.Errorf("memory p95 = %s, want %s", got, tt.wantMemory)
+			}
+			if finding.Confidence != types.ConfidenceHigh {
+				t.Errorf("confidence = %s, want %s", finding.Confidence, types.ConfidenceHigh)
+			}
+		})
+	}
+}
@@ -0,0 +1,365 @@
+package awsblades
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/ec2"
+	ec2types "github.com/aws/aws-sdk-go-v2/service/ec2/types"
+	"github.com/sirupsen/logrus"
+	"github.com/yourusername/cloudshaver/internal/inventory"
+	awspricing "github.com/yourusername/cloudshaver/internal/pricing/aws"
+	"github.com/yourusername/cloudshaver/internal/types"
+)
+
+// SpotPriceHistoryAPI is the subset of the EC2 API used to read Spot prices.
+// It can be replaced by a local stub to run the Spot analysis offline.
+type SpotPriceHistoryAPI interface {
+	ec2.DescribeSpotPriceHistoryAPIClient
+}
+
+const (
+	// autoScalingGroupTag is set by EC2 Auto Scaling on the instances it launches
+	autoScalingGroupTag = "aws:autoscaling:groupName"
+	// InterruptibleTag marks an instance whose workload tolerates
+	// interruption when set to "true"
+	InterruptibleTag = "interruptible"
+	// maxSpotHistory is how far back DescribeSpotPriceHistory reaches
+	maxSpotHistory = 90 * 24 * time.Hour
+	// maxSpotAlternatives is the number of alternative types reported
+	maxSpotAlternatives = 3
+	// highSpotVolatility is the coefficient of variation above which the
+	// Spot price of a type is considered unstable
+	highSpotVolatility = 0.2
+)
+
+// spotProductDescriptions maps the platforms Spot supports to their product
+// description in the price history
+var spotProductDescriptions = map[string]string{
+	awspricing.OperationLinux:   "Linux/UNIX",
+	awspricing.OperationWindows: "Windows",
+	awspricing.OperationRHEL:    "Red Hat Enterprise Linux",
+	awspricing.OperationSUSE:    "SUSE Linux",
+}
+
+// spotPriceStats summarizes the Spot price history of one type in one zone
+type spotPriceStats struct {
+	mean    float64
+	min     float64
+	max     float64
+	samples int
+	// volatility is the coefficient of variation of the price; frequent and
+	// large price moves reflect demand for the capacity pool and so serve as
+	// the proxy for its interruption risk
+	volatility float64
+}
+
+// spotCandidate is an instance whose workload can move to Spot
+type spotCandidate struct {
+	inventory.Instance
+	zone               string
+	productDescription string
+	platform           awspricing.Platform
+	reason             string
+}
+
+// analyzeSpotOpportunities estimates the savings of moving interruptible
+// workloads, instances in Auto Scaling groups or tagged interruptible, to
+// Spot. The Spot price history of each zone is read once for the instance
+// types in use there and their similar types, which are offered as
+// alternatives when their price has been steadier.
+func (b *EC2Blade) analyzeSpotOpportunities(ctx context.Context) ([]types.Finding, error) {
+	if b.spotPriceHistory == nil {
+		return nil, nil
+	}
+
+	instances, err := b.inventory.InstancesInState(ctx, ec2types.InstanceStateNameRunning)
+	if err != nil {
+		return nil, err
+	}
+
+	candidatesByZone := make(map[string][]spotCandidate)
+	for _, instance := range instances {
+		candidate, ok := newSpotCandidate(instance)
+		if ok {
+			candidatesByZone[candidate.zone] = append(candidatesByZone[candidate.zone], candidate)
+		}
+	}
+	if len(candidatesByZone) == 0 {
+		return nil, nil
+	}
+
+	var catalog *awspricing.InstanceCatalog
+	if b.pricingService.IsRegionSupported(b.region) {
+		catalog, err = b.pricingService.InstanceCatalog(ctx, b.region)
+		if err != nil {
+			if ctx.Err() != nil {
+				return nil, ctx.Err()
+			}
+			logrus.WithError(err).Warn("Failed to load instance catalog, Spot alternatives will not be reported")
+		}
+	}
+
+	lookback := min(b.metricsLookback, maxSpotHistory)
+
+	zones := make([]string, 0, len(candidatesByZone))
+	for zone := range candidatesByZone {
+		zones = append(zones, zone)
+	}
+	sort.Strings(zones)
+
+	var findings []types.Finding
+
+	for _, zone := range zones {
+		candidates := candidatesByZone[zone]
+
+		similar := make(map[string][]awspricing.InstanceSpec)
+		instanceTypes := make(map[string]bool)
+		for _, candidate := range candidates {
+			instanceType := string(candidate.InstanceType)
+			instanceTypes[instanceType] = true
+			if catalog != nil {
+				similar[instanceType] = catalog.SimilarTypes(instanceType)
+				for _, spec := range similar[instanceType] {
+					instanceTypes[spec.InstanceType] = true
+				}
+			}
+		}
+
+		history, err := b.fetchSpotPriceStats(ctx, zone, instanceTypes, candidates, lookback)
+		if err != nil {
+			return findings, err
+		}
+
+		for _, candidate := range candidates {
+			finding, ok, err := b.spotFinding(ctx, candidate, history, similar[string(candidate.InstanceType)], lookback)
+			if err != nil {
+				return findings, err
+			}
+			if ok {
+				findings = append(findings, finding)
+			}
+		}
+	}
+
+	return findings, nil
+}
+
+// newSpotCandidate returns the instance as a Spot candidate when its workload
+// is interruptible and Spot supports its platform
+func newSpotCandidate(instance inventory.Instance) (spotCandidate, bool) {
+	if instance.InstanceLifecycle == ec2types.InstanceLifecycleTypeSpot ||
+		instance.Placement == nil || aws.ToString(instance.Placement.AvailabilityZone) == "" {
+		return spotCandidate{}, false
+	}
+
+	var reason string
+	for _, tag := range instance.Tags {
+		switch key := aws.ToString(tag.Key); {
+		case key == autoScalingGroupTag:
+			reason = "auto scaling group " + aws.ToString(tag.Value)
+		case strings.EqualFold(key, InterruptibleTag) && strings.EqualFold(aws.ToString(tag.Value), "true") && reason == "":
+			reason = "tagged " + InterruptibleTag
+		}
+	}
+	if reason == "" {
+		return spotCandidate{}, false
+	}
+
+	platform := instancePlatform(instance)
+	productDescription, ok := spotProductDescriptions[platform.Operation]
+	if !ok {
+		return spotCandidate{}, false
+	}
+
+	return spotCandidate{
+		Instance:           instance,
+		zone:               aws.ToString(instance.Placement.AvailabilityZone),
+		productDescription: productDescription,
+		platform:           platform,
+		reason:             reason,
+	}, true
+}
+
+// fetchSpotPriceStats reads the Spot price history of the instance types in
+// a zone, keyed by product description and instance type
+func (b *EC2Blade) fetchSpotPriceStats(ctx context.Context, zone string, instanceTypes map[string]bool, candidates []spotCandidate, lookback time.Duration) (map[string]spotPriceStats, error) {
+	var typeList []ec2types.InstanceType
+	for instanceType := range instanceTypes {
+		typeList = append(typeList, ec2types.InstanceType(instanceType))
+	}
+	sort.Slice(typeList, func(i, j int) bool { return typeList[i] < typeList[j] })
+
+	descriptions := make(map[string]bool)
+	for _, candidate := range candidates {
+		descriptions[candidate.productDescription] = true
+	}
+	var descriptionList []string
+	for description := range descriptions {
+		descriptionList = append(descriptionList, description)
+	}
+	sort.Strings(descriptionList)
+
+	end := time.Now().UTC()
+	paginator := ec2.NewDescribeSpotPriceHistoryPaginator(b.spotPriceHistory, &ec2.DescribeSpotPriceHistoryInput{
+		AvailabilityZone:    aws.String(zone),
+		InstanceTypes:       typeList,
+		ProductDescriptions: descriptionList,
+		StartTime:           aws.Time(end.Add(-lookback)),
+		EndTime:             aws.Time(end),
+	})
+
+	prices := make(map[string][]float64)
+	for paginator.HasMorePages() {
+		page, err := paginator.NextPage(ctx)
+		if err != nil {
+			return nil, fmt.Errorf("failed to describe spot price history in %s: %w", zone, err)
+		}
+		for _, entry := range page.SpotPriceHistory {
+			price, err := strconv.ParseFloat(aws.ToString(entry.SpotPrice), 64)
+			if err != nil {
+				continue
+			}
+			key := spotHistoryKey(string(entry.ProductDescription), string(entry.InstanceType))
+			prices[key] = append(prices[key], price)
+		}
+	}
+
+	stats := make(map[string]spotPriceStats, len(prices))
+	for key, series := range prices {
+		stats[key] = summarizeSpotPrices(series)
+	}
+	return stats, nil
+}
+
+// spotFinding prices a candidate on Spot and picks steadier alternatives
+func (b *EC2Blade) spotFinding(ctx context.Context, candidate spotCandidate, history map[string]spotPriceStats, similar []awspricing.InstanceSpec, lookback time.Duration) (types.Finding, bool, error) {
+	instanceID := aws.ToString(candidate.InstanceId)
+	instanceType := string(candidate.InstanceType)
+
+	stats, ok := history[spotHistoryKey(candidate.productDescription, instanceType)]
+	if !ok {
+		logrus.Debugf("No Spot price history for %s in %s", instanceType, candidate.zone)
+		return types.Finding{}, false, nil
+	}
+
+	onDemand, err := b.pricingService.GetInstancePrice(ctx, instanceType, b.region, candidate.platform)
+	if err != nil {
+		if ctx.Err() != nil {
+			return types.Finding{}, false, ctx.Err()
+		}
+		logrus.WithError(err).Errorf("Failed to get price for instance %s", instanceID)
+		return types.Finding{}, false, nil
+	}
+	if stats.mean >= onDemand {
+		return types.Finding{}, false, nil
+	}
+
+	// Alternatives cost no more than the current type on Spot and their price
+	// has moved less
+	type alternative struct {
+		instanceType string
+		stats        spotPriceStats
+	}
+	var alternatives []alternative
+	for _, spec := range similar {
+		altStats, ok := history[spotHistoryKey(candidate.productDescription, spec.InstanceType)]
+		if ok && altStats.volatility < stats.volatility && altStats.mean <= stats.mean*1.1 {
+			alternatives = append(alternatives, alternative{instanceType: spec.InstanceType, stats: altStats})
+		}
+	}
+	sort.Slice(alternatives, func(i, j int) bool {
+		if alternatives[i].stats.volatility != alternatives[j].stats.volatility {
+			return alternatives[i].stats.volatility < alternatives[j].stats.volatility
+		}
+		return alternatives[i].instanceType < alternatives[j].instanceType
+	})
+	if len(alternatives) > maxSpotAlternatives {
+		alternatives = alternatives[:maxSpotAlternatives]
+	}
+
+	confidence := types.ConfidenceMedium
+	if stats.volatility > highSpotVolatility {
+		confidence = types.ConfidenceLow
+	}
+
+	details := map[string]string{
+		"instance_type":     instanceType,
+		"availability_zone": candidate.zone,
+		"platform":          candidate.platform.Name,
+		"interruptible":     candidate.reason,
+		"on_demand_hourly":  fmt.Sprintf("%.4f", onDemand),
+		"spot_mean_hourly":  fmt.Sprintf("%.4f", stats.mean),
+		"spot_min_hourly":   fmt.Sprintf("%.4f", stats.min),
+		"spot_max_hourly":   fmt.Sprintf("%.4f", stats.max),
+		"spot_volatility":   fmt.Sprintf("%.1f%%", stats.volatility*100),
+		"spot_samples":      strconv.Itoa(stats.samples),
+		"lookback_days":     strconv.Itoa(int(lookback.Hours() / 24)),
+	}
+	var names []string
+	for _, alt := range alternatives {
+		names = append(names, alt.instanceType)
+		details["alternative:"+alt.instanceType] = fmt.Sprintf("$%.4f/hour mean, %.1f%% volatility",
+			alt.stats.mean, alt.stats.volatility*100)
+	}
+
+	recommendation := fmt.Sprintf("Run on Spot (%s); mean Spot price $%.4f/hour vs $%.4f on-demand with %.1f%% volatility",
+		candidate.reason, stats.mean, onDemand, stats.volatility*100)
+	if len(names) > 0 {
+		recommendation += "; diversify across " + strings.Join(names, ", ") + " for lower interruption risk"
+	}
+
+	currentCost := onDemand * hoursPerMonth
+	projectedCost := stats.mean * hoursPerMonth
+	return types.Finding{
+		ResourceID:     instanceID,
+		ResourceARN:    ec2ARN(b.region, candidate.OwnerID, "instance", instanceID),
+		ResourceType:   "EC2 Instance",
+		Region:         b.region,
+		AccountID:      candidate.OwnerID,
+		Kind:           types.FindingSpot,
+		Recommendation: recommendation,
+		CurrentCost:    currentCost,
+		ProjectedCost:  projectedCost,
+		Savings:        currentCost - projectedCost,
+		Confidence:     confidence,
+		Details:        details,
+	}, true, nil
+}
+
+func spotHistoryKey(productDescription, instanceType string) string {
+	return productDescription + "/" + instanceType
+}
+
+// summarizeSpotPrices computes the statistics of a Spot price series
+func summarizeSpotPrices(prices []float64) spotPriceStats {
+	stats := spotPriceStats{samples: len(prices)}
+	if len(prices) == 0 {
+		return stats
+	}
+
+	stats.min, stats.max = prices[0], prices[0]
+	var sum float64
+	for _, price := range prices {
+		sum += price
+		stats.min = min(stats.min, price)
+		stats.max = max(stats.max, price)
+	}
+	stats.mean = sum / float64(len(prices))
+
+	var variance float64
+	for _, price := range prices {
+		variance += (price - stats.mean) * (price - stats.mean)
+	}
+	variance /= float64(len(prices))
+	if stats.mean > 0 {
+		stats.volatility = math.Sqrt(variance) / stats.mean
+	}
+	return stats
+}
@@ -0,0 +1,187 @@
+package awsblades
+
+import (
+	"context"
+	"math"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	ec2types "github.com/aws/aws-sdk-go-v2/service/ec2/types"
+	"github.com/yourusername/cloudshaver/internal/types"
+)
+
+// interruptibleInstance is a running m5.large tagged interruptible
+func interruptibleInstance(id string) ec2types.Instance {
+	instance := runningInstance(id, "m5.large", time.Now().Add(-30*24*time.Hour))
+	instance.Tags = []ec2types.Tag{{Key: aws.String(InterruptibleTag), Value: aws.String("true")}}
+	return instance
+}
+
+func newTestEC2Blade(t *testing.T, client *fakeEC2, opts EC2BladeOptions) *EC2Blade {
+	t.Helper()
+	opts.Pricing = newFakePrices(t)
+	blade, err := NewEC2Blade(context.Background(), client.inventory(), opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return blade
+}
+
+func TestAnalyzeSpotOpportunities(t *testing.T) {
+	tests := []struct {
+		name     string
+		instance ec2types.Instance
+		prices   []ec2types.SpotPrice
+		// wantSavings is zero when no finding is expected
+		wantSavings      float64
+		wantConfidence   types.Confidence
+		wantAlternatives []string
+	}{
+		{
+			name:           "steady price below on-demand",
+			instance:       interruptibleInstance("i-1"),
+			prices:         spotPrices("m5.large", "0.03", "0.03", "0.03"),
+			wantSavings:    (0.096 - 0.03) * hoursPerMonth,
+			wantConfidence: types.ConfidenceMedium,
+		},
+		{
+			name:           "volatility at the interruption cutoff",
+			instance:       interruptibleInstance("i-1"),
+			prices:         spotPrices("m5.large", "0.024", "0.036"),
+			wantSavings:    (0.096 - 0.03) * hoursPerMonth,
+			wantConfidence: types.ConfidenceMedium,
+		},
+		{
+			name:           "volatility above the interruption cutoff",
+			instance:       interruptibleInstance("i-1"),
+			prices:         spotPrices("m5.large", "0.02", "0.05"),
+			wantSavings:    (0.096 - 0.035) * hoursPerMonth,
+			wantConfidence: types.ConfidenceLow,
+		},
+		{
+			name:     "steadier similar types are alternatives",
+			instance: interruptibleInstance("i-1"),
+			prices: append(append(
+				spotPrices("m5.large", "0.02", "0.05"),
+				spotPrices("m6i.large", "0.038", "0.038")...),
+				// steadier, but more than 10% dearer on Spot
+				spotPrices("m5a.large", "0.04", "0.04")...),
+			wantSavings:      (0.096 - 0.035) * hoursPerMonth,
+			wantConfidence:   types.ConfidenceLow,
+			wantAlternatives: []string{"m6i.large"},
+		},
+		{
+			name:     "graviton types are not alternatives to x86 types",
+			instance: interruptibleInstance("i-1"),
+			prices: append(append(
+				spotPrices("m5.large", "0.02", "0.05"),
+				spotPrices("m6i.large", "0.038", "0.038")...),
+				// steadier and cheaper, but arm64
+				spotPrices("m6g.large", "0.03", "0.03")...),
+			wantSavings:      (0.096 - 0.035) * hoursPerMonth,
+			wantConfidence:   types.ConfidenceLow,
+			wantAlternatives: []string{"m6i.large"},
+		},
+		{
+			name:     "spot price at on-demand",
+			instance: interruptibleInstance("i-1"),
+			prices:   spotPrices("m5.large", "0.096"),
+		},
+		{
+			name:     "spot price above on-demand",
+			instance: interruptibleInstance("i-1"),
+			prices:   spotPrices("m5.large", "0.09", "0.11"),
+		},
+		{
+			name:     "no spot price history",
+			instance: interruptibleInstance("i-1"),
+			prices:   spotPrices("m6i.large", "0.03"),
+		},
+		{
+			name:     "workload not interruptible",
+			instance: runningInstance("i-1", "m5.large", time.Now()),
+			prices:   spotPrices("m5.large", "0.03"),
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			client := &fakeEC2{instances: []ec2types.Instance{tt.instance}, spotPrices: tt.prices}
+			blade := newTestEC2Blade(t, client, EC2BladeOptions{SpotPriceHistory: client})
+
+			findings, err := blade.analyzeSpotOpportunities(context.Background())
+			if err != nil {
+				t.Fatalf("analyzeSpotOpportunities: %v", err)
+			}
+			if tt.wantSavings == 0 {
+				if len(findings) != 0 {
+					t.Errorf("findings = %+v, want none", findings)
+				}
+				return
+			}
+			if len(findings) != 1 {
+				t.Fatalf("%d findings, want 1", len(findings))
+			}
+
+			finding := findings[0]
+			if math.Abs(finding.Savings-tt.wantSavings) > 1e-6 {
+				t.Errorf("savings = %.4f, want %.4f", finding.Savings, tt.wantSavings)
+			}
+			if finding.Confidence != tt.wantConfidence {
+				t.Errorf("confidence = %s, want %s", finding.Confidence, tt.wantConfidence)
+			}
+			var alternatives []string
+			for _, spec := range []string{"m5a.large", "m6g.large", "m6i.large"} {
+				if _, ok := finding.Details["alternative:"+spec]; ok {
+					alternatives = append(alternatives, spec)
+				}
+			}
+			if !slices.Equal(alternatives, tt.wantAlternatives) {
+				t.Errorf("alternatives = %v, want %v", alternatives, tt.wantAlternatives)
+			}
+		})
+	}
+}
+
+func TestEC2BladeCountsSpotOrUpgrade(t *testing.T) {
+	client := &fakeEC2{
+		instances:  []ec2types.Instance{interruptibleInstance("i-1")},
+		spotPrices: spotPrices("m5.large", "0.03", "0.03"),
+	}
+	blade := newTestEC2Blade(t, client, EC2BladeOptions{SpotPriceHistory: client})
+
+	result, err := blade.Execute(context.Background())
+	if err != nil {
+		t.Fatalf("Execute: %v", err)
+	}
+
+	if len(result.Findings) != 1 {
+		t.Fatalf("%d findings, want only the Spot move", len(result.Findings))
+	}
+	finding := result.Findings[0]
+	if finding.Kind != types.FindingSpot {
+		t.Errorf("kind = %s, want %s", finding.Kind, types.FindingSpot)
+	}
+	if _, ok := finding.Details["alternative_action:generation-upgrade"]; !ok {
+		t.Errorf("details = %v, want the generation upgrade as an alternative", finding.Details)
+	}
+	if result.PotentialSavings != finding.Savings {
+		t.Errorf("potential savings = %.2f, want the Spot savings %.2f", result.PotentialSavings, finding.Savings)
+	}
+}
+
+func TestSummarizeSpotPrices(t *testing.T) {
+	stats := summarizeSpotPrices([]float64{0.02, 0.04, 0.06})
+	if stats.samples != 3 || stats.min != 0.02 || stats.max != 0.06 || math.Abs(stats.mean-0.04) > 1e-9 {
+		t.Errorf("stats = %+v", stats)
+	}
+	if want := math.Sqrt(0.0008/3) / 0.04; math.Abs(stats.volatility-want) > 1e-9 {
+		t.Errorf("volatility = %v, want %v", stats.volatility, want)
+	}
+
+	if empty := summarizeSpotPrices(nil); empty != (spotPriceStats{}) {
+		t.Errorf("stats of no prices = %+v", empty)
+	}
+}
@@ -0,0 +1,323 @@
+package awsblades
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"math"
+	"strconv"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	ec2types "github.com/aws/aws-sdk-go-v2/service/ec2/types"
+	"github.com/sirupsen/logrus"
+	awspricing "github.com/yourusername/cloudshaver/internal/pricing/aws"
+	"github.com/yourusername/cloudshaver/internal/types"
+)
+
+const (
+	// volumeMetricPeriodSeconds is the granularity of the EBS samples;
+	// five-minute sums keep short bursts that hourly sums would average
+	// away, and CloudWatch keeps them for 63 days
+	volumeMetricPeriodSeconds = 300
+	// maxVolumeMetricsLookback is how far back five-minute samples reach
+	maxVolumeMetricsLookback = 63 * 24 * time.Hour
+	// piopsMinIOPS is the least IOPS an io1 or io2 volume can provision
+	piopsMinIOPS = 100
+	// mebibyte converts the byte metrics to MiB
+	mebibyte = 1 << 20
+)
+
+// volumeThresholds configure the right-sizing of provisioned volume
+// performance
+type volumeThresholds struct {
+	headroomPercent float64
+	minimumDays     int
+}
+
+// volumeUtilization is the observed p99 performance of one volume
+type volumeUtilization struct {
+	readIOPS        float64
+	writeIOPS       float64
+	readThroughput  float64
+	writeThroughput float64
+	samples         int
+}
+
+// iops returns the p99 IOPS of the volume. The p99 of reads and writes are
+// added, which bounds the p99 of their sum from above.
+func (u volumeUtilization) iops() float64 {
+	return u.readIOPS + u.writeIOPS
+}
+
+// throughput returns the p99 throughput of the volume in MiB/s, bounded from
+// above like iops
+func (u volumeUtilization) throughput() float64 {
+	return u.readThroughput + u.writeThroughput
+}
+
+// loadVolumeThresholds reads the underutilized volume thresholds from the
+// static pricing data, falling back to its documented defaults
+func loadVolumeThresholds() volumeThresholds {
+	thresholds := volumeThresholds{headroomPercent: 30, minimumDays: 14}
+
+	pricing, err := awspricing.LoadPricing()
+	if err != nil {
+		logrus.WithError(err).Warn("Failed to load volume thresholds, using defaults")
+		return thresholds
+	}
+
+	underutilized := pricing.SavingsOpportunities.VolumeOptimization.Underutilized
+	if underutilized.HeadroomPercent > 0 {
+		thresholds.headroomPercent = float64(underutilized.HeadroomPercent)
+	}
+	if underutilized.MinimumDays > 0 {
+		thresholds.minimumDays = underutilized.MinimumDays
+	}
+	return thresholds
+}
+
+// analyzeOverprovisionedVolumes recommends lowering the provisioned IOPS and
+// throughput of attached io1, io2 and gp3 volumes, or moving io1 and io2
+// volumes to a cheaper type, when their observed p99 usage plus headroom
+// stayed below what they provision over the lookback window. The volumes
+// reported are added to rightsized.
+func (b *EC2Blade) analyzeOverprovisionedVolumes(ctx context.Context, rightsized map[string]bool) ([]types.Finding, error) {
+	if b.cloudWatch == nil || !b.pricingService.IsRegionSupported(b.region) {
+		return nil, nil
+	}
+
+	all, err := b.inventory.Volumes(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	var volumes []ec2types.Volume
+	for _, volume := range all {
+		if volume.State != ec2types.VolumeStateInUse {
+			continue
+		}
+		switch spec := volumeSpec(volume); spec.VolumeType {
+		case "io1", "io2":
+			volumes = append(volumes, volume)
+		case "gp3":
+			if spec.IOPS > awspricing.GP3BaselineIOPS || spec.Throughput > awspricing.GP3BaselineThroughput {
+				volumes = append(volumes, volume)
+			}
+		}
+	}
+	if len(volumes) == 0 {
+		return nil, nil
+	}
+
+	thresholds := loadVolumeThresholds()
+	lookback := min(max(b.metricsLookback, time.Duration(thresholds.minimumDays)*24*time.Hour), maxVolumeMetricsLookback)
+
+	utilization, err := b.fetchVolumeUtilization(ctx, volumes, lookback, thresholds.minimumDays)
+	if err != nil {
+		return nil, err
+	}
+
+	var findings []types.Finding
+
+	for _, volume := range volumes {
+		volumeID := aws.ToString(volume.VolumeId)
+		usage, ok := utilization[volumeID]
+		if !ok {
+			continue
+		}
+
+		current := volumeSpec(volume)
+		if current.VolumeType != "gp3" {
+			current.Throughput = io1Throughput(current.IOPS)
+		}
+		headroom := 1 + thresholds.headroomPercent/100
+		neededIOPS := int(math.Ceil(usage.iops() * headroom))
+		neededThroughput := int(math.Ceil(usage.throughput() * headroom))
+
+		currentCost, err := awspricing.GetVolumeCost(ctx, b.pricingService, current, b.region)
+		if err != nil {
+			if ctx.Err() != nil {
+				return findings, ctx.Err()
+			}
+			logrus.WithError(err).Errorf("Failed to get price for volume %s", volumeID)
+			continue
+		}
+
+		var best volumeMigration
+		bestCost := currentCost.Total()
+		for _, candidate := range rightsizedVolumes(current, neededIOPS, neededThroughput) {
+			cost, err := awspricing.GetVolumeCost(ctx, b.pricingService, candidate.target, b.region)
+			if err != nil {
+				if ctx.Err() != nil {
+					return findings, ctx.Err()
+				}
+				logrus.WithError(err).Debugf("Failed to price %s for volume %s", candidate.target.VolumeType, volumeID)
+				continue
+			}
+			if cost.Total() < bestCost {
+				best, bestCost = candidate, cost.Total()
+			}
+		}
+		if best.target.VolumeType == "" {
+			continue
+		}
+
+		target := best.target
+		params, err := json.Marshal(modifyVolumeParams{
+			VolumeId:   volumeID,
+			VolumeType: target.VolumeType,
+			Iops:       target.IOPS,
+			Throughput: target.Throughput,
+		})
+		if err != nil {
+			return findings, err
+		}
+
+		lookbackDays := int(lookback.Hours() / 24)
+		recommendation := fmt.Sprintf("Lower %s volume from %d to %d IOPS", current.VolumeType, current.IOPS, target.IOPS)
+		if target.VolumeType != current.VolumeType {
+			recommendation = fmt.Sprintf("Move %s volume with %d IOPS to %s with %d IOPS", current.VolumeType, current.IOPS, target.VolumeType, target.IOPS)
+		}
+		if target.Throughput > 0 {
+			recommendation += fmt.Sprintf(" and %d MiB/s", target.Throughput)
+		}
+		recommendation += fmt.Sprintf(" (p99 %.0f IOPS, %.1f MiB/s over %d days)", usage.iops(), usage.throughput(), lookbackDays)
+		if best.caveat != "" {
+			recommendation += "; " + best.caveat
+		}
+
+		details := map[string]string{
+			"instance_id":           attachedInstance(volume),
+			"volume_type":           current.VolumeType,
+			"size_gb":               strconv.Itoa(current.SizeGB),
+			"iops":                  strconv.Itoa(current.IOPS),
+			"throughput_mibps":      strconv.Itoa(current.Throughput),
+			"read_iops_p99":         fmt.Sprintf("%.1f", usage.readIOPS),
+			"write_iops_p99":        fmt.Sprintf("%.1f", usage.writeIOPS),
+			"read_throughput_p99":   fmt.Sprintf("%.2f MiB/s", usage.readThroughput),
+			"write_throughput_p99":  fmt.Sprintf("%.2f MiB/s", usage.writeThroughput),
+			"headroom":              fmt.Sprintf("%.0f%%", thresholds.headroomPercent),
+			"lookback_days":         strconv.Itoa(lookbackDays),
+			"metric_samples":        strconv.Itoa(usage.samples),
+			"metric_period_seconds": strconv.Itoa(volumeMetricPeriodSeconds),
+			"metrics_namespace":     "AWS/EBS",
+			"target_type":           target.VolumeType,
+			"target_iops":           strconv.Itoa(target.IOPS),
+			"modify_volume":         string(params),
+		}
+		if target.Throughput > 0 {
+			details["target_throughput_mibps"] = strconv.Itoa(target.Throughput)
+		}
+
+		rightsized[volumeID] = true
+		findings = append(findings, types.Finding{
+			ResourceID:     volumeID,
+			ResourceARN:    ec2ARN(b.region, b.accountID, "volume", volumeID),
+			ResourceType:   "EBS Volume",
+			Region:         b.region,
+			AccountID:      b.accountID,
+			Kind:           types.FindingOverprovisionedVolume,
+			Recommendation: recommendation,
+			CurrentCost:    currentCost.Total(),
+			ProjectedCost:  bestCost,
+			Savings:        currentCost.Total() - bestCost,
+			Confidence:     best.confidence,
+			Details:        details,
+		})
+	}
+
+	return findings, nil
+}
+
+// fetchVolumeUtilization returns the p99 IOPS and throughput of the volumes
+// that have at least minimumDays of metric history
+func (b *EC2Blade) fetchVolumeUtilization(ctx context.Context, volumes []ec2types.Volume, lookback time.Duration, minimumDays int) (map[string]volumeUtilization, error) {
+	metrics := []string{"VolumeReadOps", "VolumeWriteOps", "VolumeReadBytes", "VolumeWriteBytes"}
+
+	var queries []metricQuery
+	for i, volume := range volumes {
+		dimensions := map[string]string{"VolumeId": aws.ToString(volume.VolumeId)}
+		for j, metric := range metrics {
+			queries = append(queries, metricQuery{
+				id:         fmt.Sprintf("v%dm%d", i, j),
+				namespace:  "AWS/EBS",
+				metricName: metric,
+				dimensions: dimensions,
+				stat:       "Sum",
+				period:     volumeMetricPeriodSeconds,
+			})
+		}
+	}
+
+	series, err := fetchMetricSeries(ctx, b.cloudWatch, queries, lookback)
+	if err != nil {
+		return nil, err
+	}
+
+	// Tolerate a few missing samples in the required history
+	minimumSamples := minimumDays * 24 * 3600 / volumeMetricPeriodSeconds * 9 / 10
+
+	// The metrics are sums over each period, turned into rates per second
+	p99 := func(i, j int, unit float64) float64 {
+		return percentile(series[fmt.Sprintf("v%dm%d", i, j)], 99) / volumeMetricPeriodSeconds / unit
+	}
+
+	utilization := make(map[string]volumeUtilization)
+	for i, volume := range volumes {
+		samples := min(len(series[fmt.Sprintf("v%dm0", i)]), len(series[fmt.Sprintf("v%dm1", i)]))
+		if samples < minimumSamples {
+			continue
+		}
+		utilization[aws.ToString(volume.VolumeId)] = volumeUtilization{
+			readIOPS:        p99(i, 0, 1),
+			writeIOPS:       p99(i, 1, 1),
+			readThroughput:  p99(i, 2, mebibyte),
+			writeThroughput: p99(i, 3, mebibyte),
+			samples:         samples,
+		}
+	}
+
+	return utilization, nil
+}
+
+// rightsizedVolumes returns the configurations of a volume delivering the
+// needed IOPS and throughput: the same type with less provisioned, and for
+// io1 and io2 the other provisioned IOPS type and gp3. Configurations
+// provisioning more than the volume has are left out.
+func rightsizedVolumes(current awspricing.VolumeSpec, neededIOPS, neededThroughput int) []volumeMigration {
+	var candidates []volumeMigration
+
+	switch current.VolumeType {
+	case "gp3":
+		target, ok := gp3Equivalent(current.SizeGB, neededIOPS, neededThroughput)
+		if ok && target.IOPS <= current.IOPS && target.Throughput <= current.Throughput {
+			candidates = append(candidates, volumeMigration{target: target, confidence: types.ConfidenceHigh})
+		}
+
+	case "io1", "io2":
+		// Provisioned IOPS volumes deliver their throughput from their IOPS
+		iops := max(neededIOPS, io1IOPS(neededThroughput), piopsMinIOPS)
+		if iops <= current.IOPS {
+			for _, volumeType := range []string{"io1", "io2"} {
+				candidates = append(candidates, volumeMigration{
+					target: awspricing.VolumeSpec{
+						VolumeType: volumeType,
+						SizeGB:     current.SizeGB,
+						IOPS:       iops,
+					},
+					confidence: types.ConfidenceHigh,
+				})
+			}
+		}
+		if target, ok := gp3Equivalent(current.SizeGB, neededIOPS, neededThroughput); ok {
+			candidates = append(candidates, volumeMigration{
+				target:     target,
+				confidence: types.ConfidenceMedium,
+				caveat:     "check the workload tolerates gp3 latency, which is higher than " + current.VolumeType,
+			})
+		}
+	}
+
+	return candidates
+}
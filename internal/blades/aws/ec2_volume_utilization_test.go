@@ -0,0 +1,106 @@
+package awsblades
+
+import (
+	"context"
+	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	ec2types "github.com/aws/aws-sdk-go-v2/service/ec2/types"
+	awspricing "github.com/yourusername/cloudshaver/internal/pricing/aws"
+	"github.com/yourusername/cloudshaver/internal/types"
+)
+
+// volumeMetric is an AWS/EBS metric of one volume
+func volumeMetric(volumeID, name string, value float64) fakeMetric {
+	return fakeMetric{namespace: "AWS/EBS", name: name, dimensions: map[string]string{"VolumeId": volumeID}, value: value}
+}
+
+func TestRightsizedVolumesSkipMigration(t *testing.T) {
+	client := &fakeEC2{volumes: []ec2types.Volume{
+		{
+			VolumeId:   aws.String("vol-io1"),
+			VolumeType: ec2types.VolumeTypeIo1,
+			Size:       aws.Int32(100),
+			Iops:       aws.Int32(5000),
+			State:      ec2types.VolumeStateInUse,
+		},
+		{
+			VolumeId:   aws.String("vol-gp2"),
+			VolumeType: ec2types.VolumeTypeGp2,
+			Size:       aws.Int32(100),
+			State:      ec2types.VolumeStateInUse,
+		},
+	}}
+	// 100 reads per second over the five-minute periods, for 20 days
+	cloudWatch := &fakeCloudWatch{
+		metrics: []fakeMetric{
+			volumeMetric("vol-io1", "VolumeReadOps", 100*volumeMetricPeriodSeconds),
+			volumeMetric("vol-io1", "VolumeWriteOps", 0),
+			volumeMetric("vol-io1", "VolumeReadBytes", mebibyte*volumeMetricPeriodSeconds),
+			volumeMetric("vol-io1", "VolumeWriteBytes", 0),
+		},
+		samples: 20 * 24 * 3600 / volumeMetricPeriodSeconds,
+	}
+	blade := newTestEC2Blade(t, client, EC2BladeOptions{CloudWatch: cloudWatch, AccountID: testAccountID})
+
+	// The volumes right-sized in one run must not leak into the next
+	for run := 1; run <= 2; run++ {
+		result, err := blade.Execute(context.Background())
+		if err != nil {
+			t.Fatalf("Execute: %v", err)
+		}
+
+		kinds := make(map[string][]types.FindingKind)
+		for _, finding := range result.Findings {
+			kinds[finding.ResourceID] = append(kinds[finding.ResourceID], finding.Kind)
+		}
+		if got := kinds["vol-io1"]; len(got) != 1 || got[0] != types.FindingOverprovisionedVolume {
+			t.Errorf("run %d: vol-io1 findings = %v, want only %s", run, got, types.FindingOverprovisionedVolume)
+		}
+		if got := kinds["vol-gp2"]; len(got) != 1 || got[0] != types.FindingVolumeMigration {
+			t.Errorf("run %d: vol-gp2 findings = %v, want only %s", run, got, types.FindingVolumeMigration)
+		}
+
+		for _, finding := range findingsOfKind(result.Findings, types.FindingOverprovisionedVolume) {
+			if finding.AccountID != testAccountID || finding.ResourceARN != "arn:aws:ec2:us-east-1:111111111111:volume/vol-io1" {
+				t.Errorf("run %d: finding has account %q and ARN %q", run, finding.AccountID, finding.ResourceARN)
+			}
+			if finding.Details["target_iops"] == "" {
+				t.Errorf("run %d: details = %v, want the target IOPS", run, finding.Details)
+			}
+		}
+	}
+}
+
+func TestRightsizedPIOPSVolumes(t *testing.T) {
+	current := awspricing.VolumeSpec{VolumeType: "io1", SizeGB: 500, IOPS: 40000}
+
+	tests := []struct {
+		name             string
+		neededIOPS       int
+		neededThroughput int
+		wantIOPS         int
+	}{
+		{name: "bound by IOPS", neededIOPS: 3000, neededThroughput: 100, wantIOPS: 3000},
+		{name: "bound by throughput", neededIOPS: 100, neededThroughput: 300, wantIOPS: 1200},
+		{name: "throughput above 500 MiB/s", neededIOPS: 100, neededThroughput: 600, wantIOPS: 38400},
+		{name: "at least the minimum", neededIOPS: 10, neededThroughput: 1, wantIOPS: piopsMinIOPS},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var got []int
+			for _, candidate := range rightsizedVolumes(current, tt.neededIOPS, tt.neededThroughput) {
+				if candidate.target.VolumeType != "gp3" {
+					got = append(got, candidate.target.IOPS)
+				}
+			}
+			if len(got) != 2 || got[0] != tt.wantIOPS || got[1] != tt.wantIOPS {
+				t.Errorf("io1 and io2 IOPS = %v, want %d", got, tt.wantIOPS)
+			}
+			if throughput := io1Throughput(tt.wantIOPS); throughput < tt.neededThroughput {
+				t.Errorf("%d IOPS deliver %d MiB/s, want at least %d", tt.wantIOPS, throughput, tt.neededThroughput)
+			}
+		})
+	}
+}
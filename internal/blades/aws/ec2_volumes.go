@@ -0,0 +1,262 @@
+package awsblades
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strconv"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	ec2types "github.com/aws/aws-sdk-go-v2/service/ec2/types"
+	"github.com/sirupsen/logrus"
+	awspricing "github.com/yourusername/cloudshaver/internal/pricing/aws"
+	"github.com/yourusername/cloudshaver/internal/types"
+)
+
+// volumeUpgrades are the newer volume types that deliver the performance of
+// an older type at no higher price
+var volumeUpgrades = map[string]string{
+	"gp2": "gp3",
+	"io1": "io2",
+}
+
+// EBS performance figures used to carry the performance of a volume over to
+// another type
+const (
+	// gp2 volumes deliver 3 IOPS per GB, at least 100 and at most 16,000.
+	// Their burst to 3,000 IOPS is covered by the gp3 baseline.
+	gp2IOPSPerGB = 3
+	gp2MinIOPS   = 100
+	gp2MaxIOPS   = 16000
+	// gp2 volumes up to 170 GB deliver 128 MiB/s and larger ones 250 MiB/s
+	gp2SmallVolumeSize       = 170
+	gp2SmallVolumeThroughput = 128
+	gp2MaxThroughput         = 250
+	// gp3 volumes provision up to 16,000 IOPS and 1,000 MiB/s, with at most
+	// 0.25 MiB/s per IOPS
+	gp3MaxIOPS           = 16000
+	gp3MaxThroughput     = 1000
+	gp3IOPSPerThroughput = 4
+	// io1 and io2 volumes deliver 256 KiB per IOPS up to 500 MiB/s, and
+	// 16 KiB per IOPS provisioned above 32,000 up to 1,000 MiB/s, as given
+	// for Provisioned IOPS SSD volumes in the Amazon EBS User Guide
+	io1IOPSPerThroughput      = 4
+	io1LargeIOPSPerThroughput = 64
+	io1LargeIOPS              = 32000
+	io1MaxSmallIOThroughput   = 500
+	io1MaxThroughput          = 1000
+)
+
+// volumeMigration is a volume configuration of another type matching the
+// performance of a volume
+type volumeMigration struct {
+	target     awspricing.VolumeSpec
+	confidence types.Confidence
+	caveat     string
+}
+
+// modifyVolumeParams are the ModifyVolume request parameters applying a
+// migration, named as in the API so they can be passed to
+// "aws ec2 modify-volume --cli-input-json"
+type modifyVolumeParams struct {
+	VolumeId   string `json:"VolumeId"`
+	VolumeType string `json:"VolumeType"`
+	Iops       int    `json:"Iops,omitempty"`
+	Throughput int    `json:"Throughput,omitempty"`
+}
+
+// analyzeVolumeMigrations recommends moving attached gp2 volumes to gp3 and
+// io1 volumes to io2 or gp3 when the new type delivers the same IOPS and
+// throughput for less. Savings are net of the IOPS and throughput the new
+// type must provision above its baseline to keep that performance.
+// Unattached volumes are left to the unattached volume analysis, and volumes
+// in rightsized, already right-sized from their metrics, to the IOPS analysis.
+func (b *EC2Blade) analyzeVolumeMigrations(ctx context.Context, rightsized map[string]bool) ([]types.Finding, error) {
+	if !b.pricingService.IsRegionSupported(b.region) {
+		return nil, nil
+	}
+
+	volumes, err := b.inventory.Volumes(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	var findings []types.Finding
+
+	for _, volume := range volumes {
+		if volume.State != ec2types.VolumeStateInUse || rightsized[aws.ToString(volume.VolumeId)] {
+			continue
+		}
+		current, migrations := volumeMigrations(volume)
+		if len(migrations) == 0 {
+			continue
+		}
+
+		volumeID := aws.ToString(volume.VolumeId)
+		currentCost, err := awspricing.GetVolumeCost(ctx, b.pricingService, current, b.region)
+		if err != nil {
+			if ctx.Err() != nil {
+				return findings, ctx.Err()
+			}
+			logrus.WithError(err).Errorf("Failed to get price for volume %s", volumeID)
+			continue
+		}
+
+		var best volumeMigration
+		bestCost := currentCost.Total()
+		for _, migration := range migrations {
+			cost, err := awspricing.GetVolumeCost(ctx, b.pricingService, migration.target, b.region)
+			if err != nil {
+				if ctx.Err() != nil {
+					return findings, ctx.Err()
+				}
+				logrus.WithError(err).Debugf("Failed to price %s for volume %s", migration.target.VolumeType, volumeID)
+				continue
+			}
+			if cost.Total() < bestCost {
+				best, bestCost = migration, cost.Total()
+			}
+		}
+		if best.target.VolumeType == "" {
+			continue
+		}
+
+		target := best.target
+		params, err := json.Marshal(modifyVolumeParams{
+			VolumeId:   volumeID,
+			VolumeType: target.VolumeType,
+			Iops:       target.IOPS,
+			Throughput: target.Throughput,
+		})
+		if err != nil {
+			return findings, err
+		}
+
+		details := map[string]string{
+			"instance_id":      attachedInstance(volume),
+			"volume_type":      current.VolumeType,
+			"size_gb":          strconv.Itoa(current.SizeGB),
+			"iops":             strconv.Itoa(current.IOPS),
+			"throughput_mibps": strconv.Itoa(current.Throughput),
+			"target_type":      target.VolumeType,
+			"target_iops":      strconv.Itoa(target.IOPS),
+			"modify_volume":    string(params),
+		}
+		recommendation := fmt.Sprintf("Modify %s volume to %s with %d IOPS", current.VolumeType, target.VolumeType, target.IOPS)
+		if target.Throughput > 0 {
+			details["target_throughput_mibps"] = strconv.Itoa(target.Throughput)
+			recommendation += fmt.Sprintf(" and %d MiB/s", target.Throughput)
+		}
+		if best.caveat != "" {
+			recommendation += "; " + best.caveat
+		}
+
+		findings = append(findings, types.Finding{
+			ResourceID:     volumeID,
+			ResourceARN:    ec2ARN(b.region, b.accountID, "volume", volumeID),
+			ResourceType:   "EBS Volume",
+			Region:         b.region,
+			AccountID:      b.accountID,
+			Kind:           types.FindingVolumeMigration,
+			Recommendation: recommendation,
+			CurrentCost:    currentCost.Total(),
+			ProjectedCost:  bestCost,
+			Savings:        currentCost.Total() - bestCost,
+			Confidence:     best.confidence,
+			Details:        details,
+		})
+	}
+
+	return findings, nil
+}
+
+// volumeMigrations returns the configuration a volume is billed for and the
+// configurations of other types delivering the same performance
+func volumeMigrations(volume ec2types.Volume) (awspricing.VolumeSpec, []volumeMigration) {
+	current := volumeSpec(volume)
+
+	switch current.VolumeType {
+	case "gp2":
+		current.IOPS, current.Throughput = gp2Performance(current.SizeGB)
+		target, ok := gp3Equivalent(current.SizeGB, current.IOPS, current.Throughput)
+		if !ok {
+			return current, nil
+		}
+		return current, []volumeMigration{{target: target, confidence: types.ConfidenceHigh}}
+
+	case "io1":
+		current.Throughput = io1Throughput(current.IOPS)
+		migrations := []volumeMigration{{
+			target: awspricing.VolumeSpec{
+				VolumeType: volumeUpgrades["io1"],
+				SizeGB:     current.SizeGB,
+				IOPS:       current.IOPS,
+			},
+			confidence: types.ConfidenceHigh,
+		}}
+		if target, ok := gp3Equivalent(current.SizeGB, current.IOPS, current.Throughput); ok {
+			migrations = append(migrations, volumeMigration{
+				target:     target,
+				confidence: types.ConfidenceMedium,
+				caveat:     "check the workload tolerates gp3 latency, which is higher than io1",
+			})
+		}
+		return current, migrations
+	}
+
+	return current, nil
+}
+
+// gp2Performance returns the baseline IOPS and throughput of a gp2 volume
+func gp2Performance(sizeGB int) (iops, throughput int) {
+	iops = min(max(sizeGB*gp2IOPSPerGB, gp2MinIOPS), gp2MaxIOPS)
+	throughput = gp2MaxThroughput
+	if sizeGB <= gp2SmallVolumeSize {
+		throughput = gp2SmallVolumeThroughput
+	}
+	return iops, throughput
+}
+
+// io1Throughput returns the throughput an io1 volume delivers at its
+// provisioned IOPS
+func io1Throughput(iops int) int {
+	if iops <= io1LargeIOPS {
+		return min(iops/io1IOPSPerThroughput, io1MaxSmallIOThroughput)
+	}
+	return min(io1MaxSmallIOThroughput+(iops-io1LargeIOPS)/io1LargeIOPSPerThroughput, io1MaxThroughput)
+}
+
+// io1IOPS returns the IOPS an io1 or io2 volume must provision to deliver
+// throughput, the inverse of io1Throughput
+func io1IOPS(throughput int) int {
+	if throughput <= io1MaxSmallIOThroughput {
+		return throughput * io1IOPSPerThroughput
+	}
+	return io1LargeIOPS + (throughput-io1MaxSmallIOThroughput)*io1LargeIOPSPerThroughput
+}
+
+// gp3Equivalent returns the gp3 configuration delivering at least iops and
+// throughput, or false if that is beyond gp3
+func gp3Equivalent(sizeGB, iops, throughput int) (awspricing.VolumeSpec, bool) {
+	throughput = max(throughput, awspricing.GP3BaselineThroughput)
+	iops = max(iops, awspricing.GP3BaselineIOPS, throughput*gp3IOPSPerThroughput)
+	if iops > gp3MaxIOPS || throughput > gp3MaxThroughput {
+		return awspricing.VolumeSpec{}, false
+	}
+	return awspricing.VolumeSpec{
+		VolumeType: volumeUpgrades["gp2"],
+		SizeGB:     sizeGB,
+		IOPS:       iops,
+		Throughput: throughput,
+	}, true
+}
+
+// attachedInstance returns the instance a volume is attached to
+func attachedInstance(volume ec2types.Volume) string {
+	for _, attachment := range volume.Attachments {
+		if id := aws.ToString(attachment.InstanceId); id != "" {
+			return id
+		}
+	}
+	return ""
+}
@@ -0,0 +1,76 @@
+package awsblades
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	ec2types "github.com/aws/aws-sdk-go-v2/service/ec2/types"
+	"github.com/yourusername/cloudshaver/internal/types"
+)
+
+func TestVolumeFindingsCarryAccount(t *testing.T) {
+	created := time.Now().Add(-60 * 24 * time.Hour)
+	client := &fakeEC2{volumes: []ec2types.Volume{
+		{
+			VolumeId:   aws.String("vol-attached"),
+			VolumeType: ec2types.VolumeTypeGp2,
+			Size:       aws.Int32(100),
+			State:      ec2types.VolumeStateInUse,
+			CreateTime: aws.Time(created),
+			Attachments: []ec2types.VolumeAttachment{{
+				InstanceId: aws.String("i-1"),
+				AttachTime: aws.Time(created),
+			}},
+		},
+		{
+			VolumeId:   aws.String("vol-unattached"),
+			VolumeType: ec2types.VolumeTypeGp2,
+			Size:       aws.Int32(100),
+			State:      ec2types.VolumeStateAvailable,
+			CreateTime: aws.Time(created),
+		},
+	}}
+
+	tests := []struct {
+		accountID string
+		wantARN   map[types.FindingKind]string
+	}{
+		{
+			accountID: testAccountID,
+			wantARN: map[types.FindingKind]string{
+				types.FindingVolumeMigration:  "arn:aws:ec2:us-east-1:111111111111:volume/vol-attached",
+				types.FindingUnattachedVolume: "arn:aws:ec2:us-east-1:111111111111:volume/vol-unattached",
+			},
+		},
+		{
+			// The scanner fills the account of ambient credentials in
+			wantARN: map[types.FindingKind]string{
+				types.FindingVolumeMigration:  "",
+				types.FindingUnattachedVolume: "",
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		// Without events the unattached volume is idle since its creation
+		cloudTrail := newTestCloudTrail(t, &fakeCloudTrail{})
+		blade := newTestEC2Blade(t, client, EC2BladeOptions{AccountID: tt.accountID, CloudTrail: cloudTrail})
+		result, err := blade.Execute(context.Background())
+		if err != nil {
+			t.Fatalf("Execute: %v", err)
+		}
+
+		for kind, wantARN := range tt.wantARN {
+			findings := findingsOfKind(result.Findings, kind)
+			if len(findings) != 1 {
+				t.Errorf("account %q: %d %s findings, want 1", tt.accountID, len(findings), kind)
+				continue
+			}
+			if got := findings[0]; got.AccountID != tt.accountID || got.ResourceARN != wantARN {
+				t.Errorf("account %q: %s finding has account %q and ARN %q, want %q", tt.accountID, kind, got.AccountID, got.ResourceARN, wantARN)
+			}
+		}
+	}
+}
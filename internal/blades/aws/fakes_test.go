@@ -0,0 +1,242 @@
+package awsblades
+
+import (
+	"context"
+	"maps"
+	"slices"
+	"sort"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/cloudwatch"
+	cwtypes "github.com/aws/aws-sdk-go-v2/service/cloudwatch/types"
+	"github.com/aws/aws-sdk-go-v2/service/ec2"
+	ec2types "github.com/aws/aws-sdk-go-v2/service/ec2/types"
+	"github.com/yourusername/cloudshaver/internal/inventory"
+	awspricing "github.com/yourusername/cloudshaver/internal/pricing/aws"
+	"github.com/yourusername/cloudshaver/internal/types"
+)
+
+const (
+	testRegion    = "us-east-1"
+	testAccountID = "111111111111"
+)
+
+// testPricing is static pricing data for the blade tests
+const testPricing = `{
+	"last_updated": "2024-01-01",
+	"region_mapping": {"us-east-1": "US East (N. Virginia)"},
+	"on_demand_instances": {"us-east-1": {
+		"t3.micro":  {"vcpu": 2, "memory_gib": 1, "price_per_hour": 0.0104},
+		"m4.large":  {"vcpu": 2, "memory_gib": 8, "price_per_hour": 0.1, "recommended_upgrade": "m5.large"},
+		"c5.large":  {"vcpu": 2, "memory_gib": 4, "price_per_hour": 0.085},
+		"m5.large":  {"vcpu": 2, "memory_gib": 8, "price_per_hour": 0.096},
+		"m5a.large": {"vcpu": 2, "memory_gib": 8, "price_per_hour": 0.086},
+		"m6i.large": {"vcpu": 2, "memory_gib": 8, "price_per_hour": 0.096},
+		"m6g.large": {"vcpu": 2, "memory_gib": 8, "price_per_hour": 0.077}
+	}},
+	"ebs_volumes": {"us-east-1": {
+		"gp2": {"price_per_gb_month": 0.1},
+		"gp3": {"price_per_gb_month": 0.08, "iops_included": 3000, "throughput_included_mibps": 125, "price_per_iops_month": 0.005, "price_per_mibps_month": 0.04},
+		"io1": {"price_per_gb_month": 0.125, "price_per_iops_month": 0.065},
+		"io2": {"price_per_gb_month": 0.125, "price_per_iops_month": 0.065}
+	}},
+	"ebs_snapshots": {"us-east-1": {"price_per_gb_month": 0.05, "archive_price_per_gb_month": 0.0125}},
+	"savings_opportunities": {}
+}`
+
+// fakePrices prices from testPricing, with commitment prices by instance
+// type and commitment kind
+type fakePrices struct {
+	*awspricing.EC2Pricing
+	commitments map[string]awspricing.CommitmentPrice
+}
+
+func newFakePrices(t *testing.T) *fakePrices {
+	t.Helper()
+	pricing, err := awspricing.ParsePricing([]byte(testPricing))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return &fakePrices{EC2Pricing: pricing, commitments: make(map[string]awspricing.CommitmentPrice)}
+}
+
+func (p *fakePrices) GetCommitmentPrice(ctx context.Context, instanceType, region string, platform awspricing.Platform, terms awspricing.CommitmentTerms) (awspricing.CommitmentPrice, error) {
+	if price, ok := p.commitments[instanceType+"/"+string(terms.Kind)]; ok {
+		price.CommitmentTerms = terms
+		return price, nil
+	}
+	return p.EC2Pricing.GetCommitmentPrice(ctx, instanceType, region, platform, terms)
+}
+
+// fakeEC2 serves canned EC2 resources of testAccountID
+type fakeEC2 struct {
+	instances         []ec2types.Instance
+	volumes           []ec2types.Volume
+	reservedInstances []ec2types.ReservedInstances
+	spotPrices        []ec2types.SpotPrice
+	snapshots         []ec2types.Snapshot
+	images            []ec2types.Image
+}
+
+func (f *fakeEC2) DescribeInstances(ctx context.Context, params *ec2.DescribeInstancesInput, optFns ...func(*ec2.Options)) (*ec2.DescribeInstancesOutput, error) {
+	return &ec2.DescribeInstancesOutput{Reservations: []ec2types.Reservation{{
+		OwnerId:   aws.String(testAccountID),
+		Instances: f.instances,
+	}}}, nil
+}
+
+func (f *fakeEC2) DescribeVolumes(ctx context.Context, params *ec2.DescribeVolumesInput, optFns ...func(*ec2.Options)) (*ec2.DescribeVolumesOutput, error) {
+	return &ec2.DescribeVolumesOutput{Volumes: f.volumes}, nil
+}
+
+func (f *fakeEC2) DescribeReservedInstances(ctx context.Context, params *ec2.DescribeReservedInstancesInput, optFns ...func(*ec2.Options)) (*ec2.DescribeReservedInstancesOutput, error) {
+	return &ec2.DescribeReservedInstancesOutput{ReservedInstances: f.reservedInstances}, nil
+}
+
+func (f *fakeEC2) DescribeSnapshots(ctx context.Context, params *ec2.DescribeSnapshotsInput, optFns ...func(*ec2.Options)) (*ec2.DescribeSnapshotsOutput, error) {
+	return &ec2.DescribeSnapshotsOutput{Snapshots: f.snapshots}, nil
+}
+
+func (f *fakeEC2) DescribeImages(ctx context.Context, params *ec2.DescribeImagesInput, optFns ...func(*ec2.Options)) (*ec2.DescribeImagesOutput, error) {
+	return &ec2.DescribeImagesOutput{Images: f.images}, nil
+}
+
+// DescribeSpotPriceHistory serves the Spot prices matching the zone, instance
+// types and product descriptions of the request, one page per price
+func (f *fakeEC2) DescribeSpotPriceHistory(ctx context.Context, params *ec2.DescribeSpotPriceHistoryInput, optFns ...func(*ec2.Options)) (*ec2.DescribeSpotPriceHistoryOutput, error) {
+	var matched []ec2types.SpotPrice
+	for _, price := range f.spotPrices {
+		if aws.ToString(price.AvailabilityZone) == aws.ToString(params.AvailabilityZone) &&
+			slices.Contains(params.InstanceTypes, price.InstanceType) &&
+			slices.Contains(params.ProductDescriptions, string(price.ProductDescription)) {
+			matched = append(matched, price)
+		}
+	}
+
+	start := 0
+	if params.NextToken != nil {
+		start, _ = strconv.Atoi(aws.ToString(params.NextToken))
+	}
+	output := &ec2.DescribeSpotPriceHistoryOutput{}
+	if start < len(matched) {
+		output.SpotPriceHistory = matched[start : start+1]
+		if start+1 < len(matched) {
+			output.NextToken = aws.String(strconv.Itoa(start + 1))
+		}
+	}
+	return output, nil
+}
+
+func (f *fakeEC2) inventory() *inventory.EC2Inventory {
+	return inventory.NewEC2Inventory(f, testRegion)
+}
+
+// fakeMetric is a metric published at a constant value for its whole
+// history
+type fakeMetric struct {
+	namespace  string
+	name       string
+	dimensions map[string]string
+	value      float64
+}
+
+// fakeCloudWatch serves published metrics. Like CloudWatch, GetMetricData
+// only matches a metric queried with exactly its dimensions.
+type fakeCloudWatch struct {
+	metrics []fakeMetric
+	samples int
+}
+
+func (f *fakeCloudWatch) GetMetricData(ctx context.Context, params *cloudwatch.GetMetricDataInput, optFns ...func(*cloudwatch.Options)) (*cloudwatch.GetMetricDataOutput, error) {
+	output := &cloudwatch.GetMetricDataOutput{}
+	for _, query := range params.MetricDataQueries {
+		metric := query.MetricStat.Metric
+		dimensions := make(map[string]string)
+		for _, dimension := range metric.Dimensions {
+			dimensions[aws.ToString(dimension.Name)] = aws.ToString(dimension.Value)
+		}
+		for _, published := range f.metrics {
+			if published.namespace != aws.ToString(metric.Namespace) || published.name != aws.ToString(metric.MetricName) ||
+				!maps.Equal(published.dimensions, dimensions) {
+				continue
+			}
+			values := make([]float64, f.samples)
+			for i := range values {
+				values[i] = published.value
+			}
+			output.MetricDataResults = append(output.MetricDataResults, cwtypes.MetricDataResult{Id: query.Id, Values: values})
+		}
+	}
+	return output, nil
+}
+
+func (f *fakeCloudWatch) ListMetrics(ctx context.Context, params *cloudwatch.ListMetricsInput, optFns ...func(*cloudwatch.Options)) (*cloudwatch.ListMetricsOutput, error) {
+	output := &cloudwatch.ListMetricsOutput{}
+	for _, published := range f.metrics {
+		if params.Namespace != nil && published.namespace != *params.Namespace ||
+			params.MetricName != nil && published.name != *params.MetricName {
+			continue
+		}
+		matched := true
+		for _, filter := range params.Dimensions {
+			value, ok := published.dimensions[aws.ToString(filter.Name)]
+			matched = matched && ok && (filter.Value == nil || value == *filter.Value)
+		}
+		if !matched {
+			continue
+		}
+
+		metric := cwtypes.Metric{Namespace: aws.String(published.namespace), MetricName: aws.String(published.name)}
+		var names []string
+		for name := range published.dimensions {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		for _, name := range names {
+			metric.Dimensions = append(metric.Dimensions, cwtypes.Dimension{Name: aws.String(name), Value: aws.String(published.dimensions[name])})
+		}
+		output.Metrics = append(output.Metrics, metric)
+	}
+	return output, nil
+}
+
+// runningInstance is a Linux instance running since launched
+func runningInstance(id, instanceType string, launched time.Time) ec2types.Instance {
+	return ec2types.Instance{
+		InstanceId:      aws.String(id),
+		InstanceType:    ec2types.InstanceType(instanceType),
+		LaunchTime:      aws.Time(launched),
+		State:           &ec2types.InstanceState{Name: ec2types.InstanceStateNameRunning},
+		Placement:       &ec2types.Placement{AvailabilityZone: aws.String(testRegion + "a")},
+		PlatformDetails: aws.String("Linux/UNIX"),
+	}
+}
+
+// spotPrices is a Linux Spot price history of an instance type in the
+// first zone of testRegion
+func spotPrices(instanceType string, prices ...string) []ec2types.SpotPrice {
+	var history []ec2types.SpotPrice
+	for _, price := range prices {
+		history = append(history, ec2types.SpotPrice{
+			AvailabilityZone:   aws.String(testRegion + "a"),
+			InstanceType:       ec2types.InstanceType(instanceType),
+			ProductDescription: ec2types.RIProductDescription("Linux/UNIX"),
+			SpotPrice:          aws.String(price),
+		})
+	}
+	return history
+}
+
+// findingsOfKind returns the findings of one kind
+func findingsOfKind(findings []types.Finding, kind types.FindingKind) []types.Finding {
+	var matched []types.Finding
+	for _, finding := range findings {
+		if finding.Kind == kind {
+			matched = append(matched, finding)
+		}
+	}
+	return matched
+}
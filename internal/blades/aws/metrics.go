@@ -0,0 +1,119 @@
+package awsblades
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"sort"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/cloudwatch"
+	cwtypes "github.com/aws/aws-sdk-go-v2/service/cloudwatch/types"
+)
+
+// CloudWatchAPI is the subset of the CloudWatch API used by the blades
+type CloudWatchAPI interface {
+	cloudwatch.GetMetricDataAPIClient
+	cloudwatch.ListMetricsAPIClient
+}
+
+// DefaultMetricsLookback is the window of CloudWatch metrics examined when
+// no lookback is configured
+const DefaultMetricsLookback = 14 * 24 * time.Hour
+
+// metricPeriodSeconds is the granularity of the metric samples fetched
+const metricPeriodSeconds = 3600
+
+// maxQueriesPerRequest is the GetMetricData limit on queries per request
+const maxQueriesPerRequest = 500
+
+// metricQuery describes one metric series to fetch
+type metricQuery struct {
+	id         string
+	namespace  string
+	metricName string
+	dimensions map[string]string
+	stat       string
+	// period is the granularity of the samples in seconds;
+	// metricPeriodSeconds is used when zero
+	period int32
+}
+
+// fetchMetricSeries fetches the samples of every query, hourly unless the
+// query sets a period, over the lookback window ending now, keyed by query
+// ID. Series without data are omitted.
+func fetchMetricSeries(ctx context.Context, client CloudWatchAPI, queries []metricQuery, lookback time.Duration) (map[string][]float64, error) {
+	end := time.Now().UTC().Truncate(time.Hour)
+	start := end.Add(-lookback)
+	series := make(map[string][]float64)
+
+	for offset := 0; offset < len(queries); offset += maxQueriesPerRequest {
+		batch := queries[offset:min(offset+maxQueriesPerRequest, len(queries))]
+
+		dataQueries := make([]cwtypes.MetricDataQuery, 0, len(batch))
+		for _, query := range batch {
+			period := query.period
+			if period == 0 {
+				period = metricPeriodSeconds
+			}
+			var dimensions []cwtypes.Dimension
+			for name, value := range query.dimensions {
+				dimensions = append(dimensions, cwtypes.Dimension{
+					Name:  aws.String(name),
+					Value: aws.String(value),
+				})
+			}
+			dataQueries = append(dataQueries, cwtypes.MetricDataQuery{
+				Id: aws.String(query.id),
+				MetricStat: &cwtypes.MetricStat{
+					Metric: &cwtypes.Metric{
+						Namespace:  aws.String(query.namespace),
+						MetricName: aws.String(query.metricName),
+						Dimensions: dimensions,
+					},
+					Period: aws.Int32(period),
+					Stat:   aws.String(query.stat),
+				},
+			})
+		}
+
+		paginator := cloudwatch.NewGetMetricDataPaginator(client, &cloudwatch.GetMetricDataInput{
+			StartTime:         aws.Time(start),
+			EndTime:           aws.Time(end),
+			MetricDataQueries: dataQueries,
+		})
+		for paginator.HasMorePages() {
+			page, err := paginator.NextPage(ctx)
+			if err != nil {
+				return nil, fmt.Errorf("failed to get metric data: %w", err)
+			}
+			for _, result := range page.MetricDataResults {
+				if len(result.Values) > 0 {
+					id := aws.ToString(result.Id)
+					series[id] = append(series[id], result.Values...)
+				}
+			}
+		}
+	}
+
+	return series, nil
+}
+
+// percentile returns the p-th percentile (0-100) of values using the
+// nearest-rank method
+func percentile(values []float64, p float64) float64 {
+	if len(values) == 0 {
+		return 0
+	}
+
+	sorted := make([]float64, len(values))
+	copy(sorted, values)
+	sort.Float64s(sorted)
+
+	rank := int(math.Ceil(p / 100 * float64(len(sorted))))
+	if rank < 1 {
+		rank = 1
+	}
+	return sorted[rank-1]
+}
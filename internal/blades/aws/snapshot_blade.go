@@ -0,0 +1,526 @@
+package awsblades
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/ec2"
+	ec2types "github.com/aws/aws-sdk-go-v2/service/ec2/types"
+	"github.com/sirupsen/logrus"
+	"github.com/yourusername/cloudshaver/internal/inventory"
+	awspricing "github.com/yourusername/cloudshaver/internal/pricing/aws"
+	pricingclient "github.com/yourusername/cloudshaver/internal/pricing/client"
+	"github.com/yourusername/cloudshaver/internal/registry"
+	"github.com/yourusername/cloudshaver/internal/types"
+)
+
+func init() {
+	registry.Register(registry.Registration{
+		Name:        "ebs-snapshots",
+		Description: "EBS snapshot hygiene: orphaned and expired snapshots, redundant daily chains and archive tier candidates",
+		Provider:    types.AWS,
+		Category:    types.StorageOptimization,
+		Services:    []string{"ec2", "pricing"},
+		New: func(ctx context.Context, env registry.Env) (types.Blade, error) {
+			return NewSnapshotBlade(ctx, env.EC2Inventory, SnapshotBladeOptions{
+				EC2:           ec2.NewFromConfig(env.AWSConfig),
+				Retention:     env.SnapshotRetention,
+				PricingSource: env.PricingSource,
+				Pricing:       env.Pricing,
+			})
+		},
+	})
+}
+
+// DefaultSnapshotRetention is the age beyond which snapshots are reported as
+// expired when no retention policy is configured
+const DefaultSnapshotRetention = 365 * 24 * time.Hour
+
+const (
+	// snapshotDailyWindow is how long every snapshot of a chain is kept;
+	// older ones are thinned to the newest of each week
+	snapshotDailyWindow = 7 * 24 * time.Hour
+	// snapshotArchiveAge is the age from which a snapshot is an archive
+	// candidate; archived snapshots are billed for at least 90 days
+	snapshotArchiveAge = 90 * 24 * time.Hour
+	// snapshotDailyChangeRate is the share of a volume assumed to change
+	// each day, used to estimate the size of incremental snapshots
+	snapshotDailyChangeRate = 0.02
+	// copiedSnapshotVolume is the volume ID of snapshots created by copying
+	copiedSnapshotVolume = "vol-ffffffff"
+)
+
+// storedSizeEstimate notes in the findings that a stored size assumes the
+// daily change rate, as the actual changes of the volume are unknown
+var storedSizeEstimate = fmt.Sprintf("estimated assuming %g%% of the volume changes per day", snapshotDailyChangeRate*100)
+
+// createImageDescription matches the description of the snapshots created
+// with an AMI
+var createImageDescription = regexp.MustCompile(`^Created by CreateImage\(.*\) for (ami-[0-9a-f]+)`)
+
+// SnapshotAPI is the subset of the EC2 API used to list snapshots and the
+// AMIs that use them
+type SnapshotAPI interface {
+	ec2.DescribeSnapshotsAPIClient
+	ec2.DescribeImagesAPIClient
+}
+
+// SnapshotBladeOptions configures the snapshot hygiene blade
+type SnapshotBladeOptions struct {
+	EC2 SnapshotAPI
+	// Retention is the age beyond which snapshots are reported as expired;
+	// DefaultSnapshotRetention is used when zero
+	Retention time.Duration
+	// PricingSource supplies price lists; nil downloads them with the
+	// default disk cache
+	PricingSource pricingclient.Source
+	// Pricing prices resources instead of the offer files of PricingSource
+	// when set, e.g. with fake prices in tests
+	Pricing awspricing.PriceProvider
+}
+
+// SnapshotBlade reports the EBS snapshots of an account and region that can
+// be deleted or archived. Snapshots are incremental and their billed size is
+// not reported by the API, so each is priced at an estimate of the data only
+// it holds: the full volume size for the newest snapshot of a volume, and
+// the data changed until the next snapshot for older ones.
+type SnapshotBlade struct {
+	inventory      *inventory.EC2Inventory
+	pricingService awspricing.PriceProvider
+	ec2            SnapshotAPI
+	retention      time.Duration
+	region         string
+	now            time.Time
+}
+
+// ownedSnapshot is a completed snapshot owned by the account
+type ownedSnapshot struct {
+	ec2types.Snapshot
+	id string
+	// volumeID is empty for copied snapshots
+	volumeID string
+	created  time.Time
+	sizeGB   int
+	// storedGB estimates the data billed for this snapshot alone, which
+	// deleting it frees
+	storedGB float64
+	// changeEstimated is set when storedGB is derived from
+	// snapshotDailyChangeRate rather than the volume size
+	changeEstimated bool
+	// image is the owned AMI using the snapshot, if any
+	image string
+	// flagged is set once a finding covers the snapshot, so later analyses
+	// skip it
+	flagged bool
+}
+
+// snapshotInventory is the state the snapshot analyses share
+type snapshotInventory struct {
+	snapshots []*ownedSnapshot
+	// byVolume holds the standard tier snapshots of each volume, oldest first
+	byVolume     map[string][]*ownedSnapshot
+	images       map[string]bool
+	volumes      map[string]bool
+	price        float64
+	archivePrice float64
+}
+
+func NewSnapshotBlade(ctx context.Context, inv *inventory.EC2Inventory, opts SnapshotBladeOptions) (*SnapshotBlade, error) {
+	pricingService := opts.Pricing
+	if pricingService == nil {
+		service, err := awspricing.NewEC2PricingService(ctx, opts.PricingSource)
+		if err != nil {
+			return nil, fmt.Errorf("failed to create pricing service: %w", err)
+		}
+		pricingService = service
+	}
+
+	retention := opts.Retention
+	if retention <= 0 {
+		retention = DefaultSnapshotRetention
+	}
+
+	return &SnapshotBlade{
+		inventory:      inv,
+		pricingService: pricingService,
+		ec2:            opts.EC2,
+		retention:      retention,
+		region:         inv.Region(),
+	}, nil
+}
+
+func (b *SnapshotBlade) GetName() string {
+	return "EBS Snapshot Blade"
+}
+
+func (b *SnapshotBlade) GetCategory() string {
+	return string(types.StorageOptimization)
+}
+
+func (b *SnapshotBlade) Execute(ctx context.Context) (*types.BladeResult, error) {
+	result := &types.BladeResult{
+		CloudProvider:    string(types.AWS),
+		Category:         string(types.StorageOptimization),
+		ResourceType:     "EBS Snapshots",
+		PotentialSavings: 0,
+		Recommendations:  []string{},
+		Details:          make(map[string]string),
+		Findings:         []types.Finding{},
+		Timestamp:        time.Now(),
+	}
+	b.now = result.Timestamp
+
+	// Each analysis skips the snapshots an earlier one reported, so a
+	// snapshot is never counted twice
+	var inv *snapshotInventory
+	afterInventory := func(analyze func(context.Context, *snapshotInventory) ([]types.Finding, error)) func(context.Context) ([]types.Finding, error) {
+		return func(ctx context.Context) ([]types.Finding, error) {
+			if inv == nil {
+				return nil, nil
+			}
+			return analyze(ctx, inv)
+		}
+	}
+	err := runAnalyses(ctx, b.GetName(), result, []bladeAnalysis{
+		{name: "snapshot inventory", run: func(ctx context.Context) ([]types.Finding, error) {
+			var err error
+			inv, err = b.snapshotInventory(ctx)
+			return nil, err
+		}},
+		{name: "orphaned snapshots", run: afterInventory(b.analyzeOrphanedSnapshots)},
+		{name: "expired snapshots", run: afterInventory(b.analyzeExpiredSnapshots)},
+		{name: "redundant snapshot chains", run: afterInventory(b.analyzeRedundantChains)},
+		{name: "snapshot archive candidates", run: afterInventory(b.analyzeArchiveCandidates)},
+	})
+
+	if inv != nil {
+		var imageSnapshots int
+		for _, snapshot := range inv.snapshots {
+			if snapshot.image != "" {
+				imageSnapshots++
+			}
+		}
+		result.Details["snapshots"] = strconv.Itoa(len(inv.snapshots))
+		result.Details["ami_snapshots"] = strconv.Itoa(imageSnapshots)
+	}
+
+	summarizeFindings(result)
+	return result, err
+}
+
+// snapshotInventory lists the completed snapshots and AMIs owned by the
+// account, the volumes of the region and the snapshot prices, and estimates
+// the storage of each snapshot
+func (b *SnapshotBlade) snapshotInventory(ctx context.Context) (*snapshotInventory, error) {
+	inv := &snapshotInventory{
+		byVolume: make(map[string][]*ownedSnapshot),
+		images:   make(map[string]bool),
+		volumes:  make(map[string]bool),
+	}
+
+	imageSnapshots := make(map[string]string)
+	images := ec2.NewDescribeImagesPaginator(b.ec2, &ec2.DescribeImagesInput{Owners: []string{"self"}})
+	for images.HasMorePages() {
+		page, err := images.NextPage(ctx)
+		if err != nil {
+			return nil, fmt.Errorf("failed to list AMIs: %w", err)
+		}
+		for _, image := range page.Images {
+			imageID := aws.ToString(image.ImageId)
+			inv.images[imageID] = true
+			for _, mapping := range image.BlockDeviceMappings {
+				if mapping.Ebs != nil && mapping.Ebs.SnapshotId != nil {
+					imageSnapshots[aws.ToString(mapping.Ebs.SnapshotId)] = imageID
+				}
+			}
+		}
+	}
+
+	volumes, err := b.inventory.Volumes(ctx)
+	if err != nil {
+		return nil, err
+	}
+	for _, volume := range volumes {
+		inv.volumes[aws.ToString(volume.VolumeId)] = true
+	}
+
+	snapshots := ec2.NewDescribeSnapshotsPaginator(b.ec2, &ec2.DescribeSnapshotsInput{OwnerIds: []string{"self"}})
+	for snapshots.HasMorePages() {
+		page, err := snapshots.NextPage(ctx)
+		if err != nil {
+			return nil, fmt.Errorf("failed to list snapshots: %w", err)
+		}
+		for _, snapshot := range page.Snapshots {
+			if snapshot.State != ec2types.SnapshotStateCompleted {
+				continue
+			}
+			owned := &ownedSnapshot{
+				Snapshot: snapshot,
+				id:       aws.ToString(snapshot.SnapshotId),
+				volumeID: aws.ToString(snapshot.VolumeId),
+				created:  aws.ToTime(snapshot.StartTime),
+				sizeGB:   int(aws.ToInt32(snapshot.VolumeSize)),
+			}
+			if owned.volumeID == copiedSnapshotVolume {
+				owned.volumeID = ""
+			}
+			owned.image = imageSnapshots[owned.id]
+			owned.storedGB = float64(owned.sizeGB)
+			inv.snapshots = append(inv.snapshots, owned)
+			if owned.volumeID != "" && snapshot.StorageTier != ec2types.StorageTierArchive {
+				inv.byVolume[owned.volumeID] = append(inv.byVolume[owned.volumeID], owned)
+			}
+		}
+	}
+
+	for _, chain := range inv.byVolume {
+		sort.Slice(chain, func(i, j int) bool {
+			return chain[i].created.Before(chain[j].created)
+		})
+		// Blocks overwritten before the next snapshot are held by this one
+		// alone; the newest holds the rest of the volume
+		for i, snapshot := range chain[:len(chain)-1] {
+			days := chain[i+1].created.Sub(snapshot.created).Hours() / 24
+			snapshot.storedGB = float64(snapshot.sizeGB) * min(days*snapshotDailyChangeRate, 1)
+			snapshot.changeEstimated = true
+		}
+	}
+
+	if inv.price, err = b.pricingService.GetSnapshotPrice(ctx, b.region); err != nil {
+		if ctx.Err() != nil {
+			return nil, ctx.Err()
+		}
+		logrus.WithError(err).Warnf("Snapshot pricing not available in %s", b.region)
+	}
+	if inv.archivePrice, err = b.pricingService.GetSnapshotArchivePrice(ctx, b.region); err != nil {
+		if ctx.Err() != nil {
+			return nil, ctx.Err()
+		}
+		logrus.WithError(err).Debugf("Snapshot archive pricing not available in %s", b.region)
+	}
+
+	return inv, nil
+}
+
+// analyzeOrphanedSnapshots reports snapshots created for an AMI that has
+// been deregistered, and snapshots whose source volume has been deleted
+func (b *SnapshotBlade) analyzeOrphanedSnapshots(ctx context.Context, inv *snapshotInventory) ([]types.Finding, error) {
+	var findings []types.Finding
+
+	for _, snapshot := range inv.snapshots {
+		if snapshot.image != "" {
+			continue
+		}
+
+		var finding types.Finding
+		if match := createImageDescription.FindStringSubmatch(aws.ToString(snapshot.Description)); match != nil && !inv.images[match[1]] {
+			finding = b.snapshotFinding(inv, snapshot, types.FindingOrphanedSnapshot,
+				fmt.Sprintf("Delete snapshot of deregistered AMI %s", match[1]), types.ConfidenceHigh)
+			finding.Details["image_id"] = match[1]
+		} else if snapshot.volumeID != "" && !inv.volumes[snapshot.volumeID] {
+			finding = b.snapshotFinding(inv, snapshot, types.FindingOrphanedSnapshot,
+				fmt.Sprintf("Delete snapshot of deleted volume %s, or archive it if the data must be kept", snapshot.volumeID),
+				types.ConfidenceMedium)
+			if savings := b.archiveSavings(inv, snapshot); savings > 0 {
+				finding.Details["archive_savings"] = fmt.Sprintf("%.2f", savings)
+			}
+		} else {
+			continue
+		}
+
+		snapshot.flagged = true
+		findings = append(findings, finding)
+	}
+
+	return findings, nil
+}
+
+// analyzeExpiredSnapshots reports snapshots older than the retention policy
+// that no AMI uses
+func (b *SnapshotBlade) analyzeExpiredSnapshots(ctx context.Context, inv *snapshotInventory) ([]types.Finding, error) {
+	cutoff := b.now.Add(-b.retention)
+	var findings []types.Finding
+
+	for _, snapshot := range inv.snapshots {
+		if snapshot.flagged || snapshot.image != "" || !snapshot.created.Before(cutoff) {
+			continue
+		}
+
+		finding := b.snapshotFinding(inv, snapshot, types.FindingExpiredSnapshot,
+			fmt.Sprintf("Delete snapshot older than the %d day retention policy", int(b.retention.Hours()/24)),
+			types.ConfidenceMedium)
+		snapshot.flagged = true
+		findings = append(findings, finding)
+	}
+
+	return findings, nil
+}
+
+// analyzeRedundantChains thins the snapshot chain of each volume to the
+// newest snapshot of every week once snapshots are older than the daily
+// window, reporting the snapshots in between as redundant
+func (b *SnapshotBlade) analyzeRedundantChains(ctx context.Context, inv *snapshotInventory) ([]types.Finding, error) {
+	cutoff := b.now.Add(-snapshotDailyWindow)
+	var findings []types.Finding
+
+	for _, volumeID := range sortedVolumeIDs(inv.byVolume) {
+		chain := inv.byVolume[volumeID]
+
+		// The chain is oldest first, so a week's snapshot is redundant when
+		// the next one falls in the same week
+		var redundant []*ownedSnapshot
+		for i, snapshot := range chain[:len(chain)-1] {
+			next := chain[i+1]
+			if snapshot.flagged || snapshot.image != "" || !next.created.Before(cutoff) {
+				continue
+			}
+			year, week := snapshot.created.ISOWeek()
+			nextYear, nextWeek := next.created.ISOWeek()
+			if year == nextYear && week == nextWeek {
+				redundant = append(redundant, snapshot)
+			}
+		}
+		if len(redundant) == 0 {
+			continue
+		}
+
+		var storedGB float64
+		ids := make([]string, len(redundant))
+		for i, snapshot := range redundant {
+			storedGB += snapshot.storedGB
+			ids[i] = snapshot.id
+			snapshot.flagged = true
+		}
+		monthlyCost := storedGB * inv.price
+
+		finding := types.Finding{
+			ResourceID:   volumeID,
+			ResourceType: "EBS Snapshot Chain",
+			Region:       b.region,
+			AccountID:    aws.ToString(chain[0].OwnerId),
+			Kind:         types.FindingRedundantSnapshots,
+			Recommendation: fmt.Sprintf("Delete %d of %d snapshots to keep one per week beyond %d days",
+				len(redundant), len(chain), int(snapshotDailyWindow.Hours()/24)),
+			CurrentCost:   monthlyCost,
+			ProjectedCost: 0,
+			Savings:       monthlyCost,
+			Confidence:    types.ConfidenceLow,
+			Details: map[string]string{
+				"snapshots":           strconv.Itoa(len(chain)),
+				"redundant_snapshots": strings.Join(ids, ","),
+				"estimated_stored_gb": fmt.Sprintf("%.1f", storedGB),
+				"stored_size":         storedSizeEstimate,
+			},
+		}
+		if inv.price == 0 {
+			finding.Details["pricing"] = "not available"
+		}
+		findings = append(findings, finding)
+	}
+
+	return findings, nil
+}
+
+// analyzeArchiveCandidates recommends moving the snapshots older than the
+// archive age that are the only standard tier snapshot of their volume to
+// the archive tier. Archived snapshots are stored in full, so archiving a
+// snapshot in the middle of a chain rarely saves anything.
+func (b *SnapshotBlade) analyzeArchiveCandidates(ctx context.Context, inv *snapshotInventory) ([]types.Finding, error) {
+	if inv.archivePrice == 0 || inv.price == 0 {
+		return nil, nil
+	}
+
+	cutoff := b.now.Add(-snapshotArchiveAge)
+	var findings []types.Finding
+
+	for _, snapshot := range inv.snapshots {
+		if snapshot.flagged || snapshot.image != "" ||
+			snapshot.StorageTier == ec2types.StorageTierArchive ||
+			!snapshot.created.Before(cutoff) ||
+			snapshot.volumeID != "" && len(inv.byVolume[snapshot.volumeID]) > 1 {
+			continue
+		}
+
+		savings := b.archiveSavings(inv, snapshot)
+		if savings <= 0 {
+			continue
+		}
+
+		finding := b.snapshotFinding(inv, snapshot, types.FindingSnapshotArchive,
+			"Move snapshot to the archive tier; restores take up to 72 hours and archived snapshots are billed for at least 90 days",
+			types.ConfidenceMedium)
+		finding.ProjectedCost = finding.CurrentCost - savings
+		finding.Savings = savings
+		snapshot.flagged = true
+		findings = append(findings, finding)
+	}
+
+	return findings, nil
+}
+
+// archiveSavings returns the monthly savings of moving a standard tier
+// snapshot to the archive tier, where it is stored in full
+func (b *SnapshotBlade) archiveSavings(inv *snapshotInventory, snapshot *ownedSnapshot) float64 {
+	if inv.archivePrice == 0 || snapshot.StorageTier == ec2types.StorageTierArchive {
+		return 0
+	}
+	return snapshot.storedGB*inv.price - float64(snapshot.sizeGB)*inv.archivePrice
+}
+
+// snapshotFinding reports deleting a snapshot, saving its monthly cost. The
+// confidence is lowered when the cost rests on the assumed change rate.
+func (b *SnapshotBlade) snapshotFinding(inv *snapshotInventory, snapshot *ownedSnapshot, kind types.FindingKind, recommendation string, confidence types.Confidence) types.Finding {
+	price := inv.price
+	if snapshot.StorageTier == ec2types.StorageTierArchive {
+		price = inv.archivePrice
+	}
+	monthlyCost := snapshot.storedGB * price
+
+	details := map[string]string{
+		"size_gb":             strconv.Itoa(snapshot.sizeGB),
+		"estimated_stored_gb": fmt.Sprintf("%.1f", snapshot.storedGB),
+		"created":             snapshot.created.Format(time.RFC3339),
+		"storage_tier":        string(snapshot.StorageTier),
+	}
+	if snapshot.volumeID != "" {
+		details["volume_id"] = snapshot.volumeID
+	}
+	if price == 0 {
+		details["pricing"] = "not available"
+	}
+	if snapshot.changeEstimated {
+		details["stored_size"] = storedSizeEstimate
+		confidence = types.ConfidenceLow
+	}
+
+	return types.Finding{
+		ResourceID:     snapshot.id,
+		ResourceARN:    fmt.Sprintf("arn:aws:ec2:%s::snapshot/%s", b.region, snapshot.id),
+		ResourceType:   "EBS Snapshot",
+		Region:         b.region,
+		AccountID:      aws.ToString(snapshot.OwnerId),
+		Kind:           kind,
+		Recommendation: recommendation,
+		CurrentCost:    monthlyCost,
+		ProjectedCost:  0,
+		Savings:        monthlyCost,
+		Confidence:     confidence,
+		Details:        details,
+	}
+}
+
+// sortedVolumeIDs returns the volumes with snapshots in a stable order
+func sortedVolumeIDs(byVolume map[string][]*ownedSnapshot) []string {
+	ids := make([]string, 0, len(byVolume))
+	for id := range byVolume {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+	return ids
+}
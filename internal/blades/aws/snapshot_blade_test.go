@@ -0,0 +1,98 @@
+package awsblades
+
+import (
+	"context"
+	"math"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	ec2types "github.com/aws/aws-sdk-go-v2/service/ec2/types"
+	"github.com/yourusername/cloudshaver/internal/types"
+)
+
+// completedSnapshot is a 100 GB standard tier snapshot of volumeID
+func completedSnapshot(id, volumeID string, created time.Time) ec2types.Snapshot {
+	return ec2types.Snapshot{
+		SnapshotId:  aws.String(id),
+		VolumeId:    aws.String(volumeID),
+		VolumeSize:  aws.Int32(100),
+		OwnerId:     aws.String(testAccountID),
+		State:       ec2types.SnapshotStateCompleted,
+		StartTime:   aws.Time(created),
+		StorageTier: ec2types.StorageTierStandard,
+	}
+}
+
+func TestSnapshotBladeEstimatedStoredSize(t *testing.T) {
+	now := time.Now().UTC()
+	// Monday three weeks back, so its snapshots are past the daily window
+	// and fall in one ISO week
+	monday := now.AddDate(0, 0, -21-(int(now.Weekday())+6)%7).Truncate(time.Hour)
+
+	client := &fakeEC2{
+		volumes: []ec2types.Volume{{VolumeId: aws.String("vol-live"), State: ec2types.VolumeStateInUse}},
+		snapshots: []ec2types.Snapshot{
+			// The volume is gone; the older snapshot holds 10 days of changes
+			completedSnapshot("snap-gone-old", "vol-gone", now.AddDate(0, 0, -20)),
+			completedSnapshot("snap-gone-new", "vol-gone", now.AddDate(0, 0, -10)),
+			// A daily chain of which the Monday snapshot is redundant
+			completedSnapshot("snap-live-mon", "vol-live", monday),
+			completedSnapshot("snap-live-tue", "vol-live", monday.AddDate(0, 0, 1)),
+			completedSnapshot("snap-live-next", "vol-live", monday.AddDate(0, 0, 7)),
+		},
+	}
+	blade, err := NewSnapshotBlade(context.Background(), client.inventory(), SnapshotBladeOptions{EC2: client, Pricing: newFakePrices(t)})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	result, err := blade.Execute(context.Background())
+	if err != nil {
+		t.Fatalf("Execute: %v", err)
+	}
+
+	findings := make(map[string]types.Finding)
+	for _, finding := range result.Findings {
+		findings[finding.ResourceID] = finding
+	}
+
+	tests := []struct {
+		resourceID     string
+		wantKind       types.FindingKind
+		wantStoredGB   string
+		wantSavings    float64
+		wantConfidence types.Confidence
+		wantEstimated  bool
+	}{
+		{"snap-gone-old", types.FindingOrphanedSnapshot, "20.0", 20 * 0.05, types.ConfidenceLow, true},
+		{"snap-gone-new", types.FindingOrphanedSnapshot, "100.0", 100 * 0.05, types.ConfidenceMedium, false},
+		{"vol-live", types.FindingRedundantSnapshots, "2.0", 2 * 0.05, types.ConfidenceLow, true},
+	}
+
+	for _, tt := range tests {
+		finding, ok := findings[tt.resourceID]
+		if !ok {
+			t.Errorf("no finding for %s", tt.resourceID)
+			continue
+		}
+		if finding.Kind != tt.wantKind {
+			t.Errorf("%s kind = %s, want %s", tt.resourceID, finding.Kind, tt.wantKind)
+		}
+		if got := finding.Details["estimated_stored_gb"]; got != tt.wantStoredGB {
+			t.Errorf("%s stored size = %s GB, want %s", tt.resourceID, got, tt.wantStoredGB)
+		}
+		if math.Abs(finding.Savings-tt.wantSavings) > 1e-9 {
+			t.Errorf("%s savings = %.4f, want %.4f", tt.resourceID, finding.Savings, tt.wantSavings)
+		}
+		if finding.Confidence != tt.wantConfidence {
+			t.Errorf("%s confidence = %s, want %s", tt.resourceID, finding.Confidence, tt.wantConfidence)
+		}
+		if _, ok := finding.Details["stored_size"]; ok != tt.wantEstimated {
+			t.Errorf("%s details = %v, want the stored size noted as estimated: %v", tt.resourceID, finding.Details, tt.wantEstimated)
+		}
+	}
+	if len(result.Findings) != len(tests) {
+		t.Errorf("%d findings, want %d", len(result.Findings), len(tests))
+	}
+}
@@ -2,60 +2,248 @@ package factory
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"time"
 
+	"github.com/aws/aws-sdk-go-v2/aws"
 	"github.com/aws/aws-sdk-go-v2/config"
 	"github.com/aws/aws-sdk-go-v2/service/ec2"
-	awsblades "github.com/yourusername/cloudshaver/internal/blades/aws"
+	"github.com/sirupsen/logrus"
+	awsutil "github.com/yourusername/cloudshaver/internal/aws"
+	"github.com/yourusername/cloudshaver/internal/inventory"
+	awspricing "github.com/yourusername/cloudshaver/internal/pricing/aws"
+	pricingclient "github.com/yourusername/cloudshaver/internal/pricing/client"
+	"github.com/yourusername/cloudshaver/internal/registry"
 	"github.com/yourusername/cloudshaver/internal/types"
+
+	// Register the AWS blades
+	_ "github.com/yourusername/cloudshaver/internal/blades/aws"
 )
 
-// BladeConfig represents the configuration for creating a blade
+// DefaultRegion is used to discover regions when no region is configured
+const DefaultRegion = "us-east-1"
+
+// BladeConfig represents the configuration for creating blades
 type BladeConfig struct {
 	Provider types.CloudProvider
-	Region   string
-	// Add more configuration options as needed
+	// Region is the region blades are created for by CreateBlades
+	Region string
+	// Regions lists the regions to scan; when empty only Region is scanned
+	Regions []string
+	// AllRegions scans every region enabled for the account, discovered at
+	// scan time, and takes precedence over Regions
+	AllRegions bool
+	// Account is the account blades are created for by CreateBlades; the zero
+	// value uses the ambient credentials without assuming a role
+	Account awsutil.Account
+	// Accounts lists the accounts to scan; when empty only the account of the
+	// ambient credentials is scanned
+	Accounts []awsutil.Account
+	// OrganizationAccounts scans every active account of the AWS Organization
+	// and takes precedence over Accounts
+	OrganizationAccounts bool
+	// AssumeRole configures the role assumed in each scanned account
+	AssumeRole awsutil.AssumeRoleOptions
+	// MetricsLookback is the window of CloudWatch metrics examined by
+	// utilization-based analyses; zero selects each blade's default
+	MetricsLookback time.Duration
+	// SteadyStateAge is how long an instance must have been running to count
+	// as steady-state usage for commitments; zero selects the blade's default
+	SteadyStateAge time.Duration
+	// IdleAge is how long an instance must have been stopped, or a volume
+	// unattached, to be reported; zero selects the thresholds of the static
+	// pricing data
+	IdleAge time.Duration
+	// SnapshotRetention is the age beyond which snapshots are reported as
+	// expired; zero selects the blade's default
+	SnapshotRetention time.Duration
+	// PricingSource supplies price lists; nil downloads them with the
+	// default disk cache
+	PricingSource pricingclient.Source
+	// Pricing prices resources for the blades of every account and region;
+	// nil makes each blade price from PricingSource on its own
+	Pricing awspricing.PriceProvider
+	// Include limits the blades to these names or categories; empty means all
+	Include []string
+	// Exclude drops blades matching these names or categories
+	Exclude []string
 }
 
-// CreateBlade creates a blade instance based on the provided configuration
-func CreateBlade(ctx context.Context, bladeConfig BladeConfig) (types.Blade, error) {
+// CreateBlades creates every registered blade enabled by the provided
+// configuration. Blades that fail to build are reported in the returned error
+// while the others are still returned.
+func CreateBlades(ctx context.Context, bladeConfig BladeConfig) ([]types.Blade, error) {
+	registrations, err := registry.Select(bladeConfig.Provider, bladeConfig.Include, bladeConfig.Exclude)
+	if err != nil {
+		return nil, err
+	}
+
+	var env registry.Env
 	switch bladeConfig.Provider {
 	case types.AWS:
-		return createAWSBlade(ctx, bladeConfig)
+		env, err = createAWSEnv(ctx, bladeConfig)
 	case types.Azure:
-		return createAzureBlade(ctx, bladeConfig)
+		err = createAzureEnv(ctx, bladeConfig)
 	case types.GCP:
-		return createGCPBlade(ctx, bladeConfig)
+		err = createGCPEnv(ctx, bladeConfig)
 	default:
-		return nil, fmt.Errorf("unsupported cloud provider: %s", bladeConfig.Provider)
+		err = fmt.Errorf("unsupported cloud provider: %s", bladeConfig.Provider)
 	}
+	if err != nil {
+		return nil, err
+	}
+
+	var blades []types.Blade
+	var errs []error
+	for _, registration := range registrations {
+		blade, err := registration.New(ctx, env)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("failed to create %s blade: %w", registration.Name, err))
+			continue
+		}
+		blades = append(blades, blade)
+	}
+
+	return blades, errors.Join(errs...)
 }
 
-func createAWSBlade(ctx context.Context, bladeConfig BladeConfig) (types.Blade, error) {
-	// Load AWS configuration
-	cfg, err := config.LoadDefaultConfig(ctx, config.WithRegion(bladeConfig.Region))
+// ResolveRegions returns the regions a scan with the provided configuration
+// covers
+func ResolveRegions(ctx context.Context, bladeConfig BladeConfig) ([]string, error) {
+	if !bladeConfig.AllRegions {
+		if len(bladeConfig.Regions) > 0 {
+			return bladeConfig.Regions, nil
+		}
+		if bladeConfig.Region == "" {
+			return nil, fmt.Errorf("no region configured")
+		}
+		return []string{bladeConfig.Region}, nil
+	}
+
+	if bladeConfig.Provider != types.AWS {
+		return nil, fmt.Errorf("region discovery is not supported for provider %s", bladeConfig.Provider)
+	}
+
+	cfg, err := loadAWSConfig(ctx, bladeConfig, bladeConfig.Region)
 	if err != nil {
-		return nil, fmt.Errorf("unable to load AWS SDK config: %w", err)
+		return nil, err
 	}
 
-	// Create EC2 client
-	ec2Client := ec2.NewFromConfig(cfg)
+	return awsutil.EnabledRegions(ctx, cfg)
+}
 
-	// Create EC2 blade
-	blade, err := awsblades.NewEC2Blade(ec2Client, bladeConfig.Region)
+// ResolveAccounts returns the accounts a scan with the provided configuration
+// covers. A single zero Account stands for the account of the ambient
+// credentials.
+func ResolveAccounts(ctx context.Context, bladeConfig BladeConfig) ([]awsutil.Account, error) {
+	if !bladeConfig.OrganizationAccounts && len(bladeConfig.Accounts) == 0 {
+		return []awsutil.Account{{}}, nil
+	}
+	if bladeConfig.Provider != types.AWS {
+		return nil, fmt.Errorf("multi-account scanning is not supported for provider %s", bladeConfig.Provider)
+	}
+	if bladeConfig.AssumeRole.RoleName == "" {
+		return nil, fmt.Errorf("a role to assume is required to scan multiple accounts")
+	}
+
+	// Accounts are always discovered with the ambient credentials
+	ambient := bladeConfig
+	ambient.Account = awsutil.Account{}
+
+	if bladeConfig.OrganizationAccounts {
+		cfg, err := loadAWSConfig(ctx, ambient, bladeConfig.Region)
+		if err != nil {
+			return nil, err
+		}
+		return awsutil.ListOrganizationAccounts(ctx, cfg)
+	}
+
+	accounts := make([]awsutil.Account, len(bladeConfig.Accounts))
+	copy(accounts, bladeConfig.Accounts)
+	for i, account := range accounts {
+		if account.Alias != "" {
+			continue
+		}
+
+		accountConfig := ambient
+		accountConfig.Account = account
+		cfg, err := loadAWSConfig(ctx, accountConfig, bladeConfig.Region)
+		if err != nil {
+			return nil, err
+		}
+
+		// The alias is informational, so a failed lookup does not stop the scan
+		alias, err := awsutil.LookupAccountAlias(ctx, cfg)
+		if err != nil {
+			logrus.WithError(err).WithField("account", account.ID).Warn("Failed to look up account alias")
+			continue
+		}
+		accounts[i].Alias = alias
+	}
+
+	return accounts, nil
+}
+
+// NewPricing creates the price provider shared by the blades of a scan from
+// its pricing source. It returns nil for providers without one.
+func NewPricing(ctx context.Context, bladeConfig BladeConfig) (awspricing.PriceProvider, error) {
+	if bladeConfig.Provider != types.AWS {
+		return nil, nil
+	}
+	pricing, err := awspricing.NewEC2PricingService(ctx, bladeConfig.PricingSource)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create pricing service: %w", err)
+	}
+	return pricing, nil
+}
+
+// loadAWSConfig loads the AWS configuration for region, assuming the
+// configured role when the configuration targets a specific account
+func loadAWSConfig(ctx context.Context, bladeConfig BladeConfig, region string) (aws.Config, error) {
+	if region == "" {
+		region = DefaultRegion
+	}
+
+	cfg, err := config.LoadDefaultConfig(ctx, config.WithRegion(region))
+	if err != nil {
+		return aws.Config{}, fmt.Errorf("unable to load AWS SDK config: %w", err)
+	}
+
+	if bladeConfig.Account.ID == "" {
+		return cfg, nil
+	}
+	return awsutil.AssumeRoleConfig(cfg, bladeConfig.Account, bladeConfig.AssumeRole)
+}
+
+func createAWSEnv(ctx context.Context, bladeConfig BladeConfig) (registry.Env, error) {
+	// Load AWS configuration
+	cfg, err := loadAWSConfig(ctx, bladeConfig, bladeConfig.Region)
 	if err != nil {
-		return nil, fmt.Errorf("failed to create EC2 blade: %w", err)
+		return registry.Env{}, err
 	}
 
-	return blade, nil
+	return registry.Env{
+		Provider:          types.AWS,
+		Region:            bladeConfig.Region,
+		Account:           bladeConfig.Account,
+		AWSConfig:         cfg,
+		EC2Inventory:      inventory.NewEC2Inventory(ec2.NewFromConfig(cfg), bladeConfig.Region),
+		MetricsLookback:   bladeConfig.MetricsLookback,
+		SteadyStateAge:    bladeConfig.SteadyStateAge,
+		IdleAge:           bladeConfig.IdleAge,
+		SnapshotRetention: bladeConfig.SnapshotRetention,
+		PricingSource:     bladeConfig.PricingSource,
+		Pricing:           bladeConfig.Pricing,
+	}, nil
 }
 
-func createAzureBlade(ctx context.Context, config BladeConfig) (types.Blade, error) {
+func createAzureEnv(ctx context.Context, config BladeConfig) error {
 	// TODO: Implement Azure blade creation
-	return nil, fmt.Errorf("azure blade creation not implemented")
+	return fmt.Errorf("azure blade creation not implemented")
 }
 
-func createGCPBlade(ctx context.Context, config BladeConfig) (types.Blade, error) {
+func createGCPEnv(ctx context.Context, config BladeConfig) error {
 	// TODO: Implement GCP blade creation
-	return nil, fmt.Errorf("gcp blade creation not implemented")
+	return fmt.Errorf("gcp blade creation not implemented")
 }
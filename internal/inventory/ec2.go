@@ -0,0 +1,151 @@
+package inventory
+
+import (
+	"context"
+	"fmt"
+	"sync"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/ec2"
+	ec2types "github.com/aws/aws-sdk-go-v2/service/ec2/types"
+)
+
+// EC2API is the subset of the EC2 API the inventory reads from
+type EC2API interface {
+	ec2.DescribeInstancesAPIClient
+	ec2.DescribeVolumesAPIClient
+}
+
+// Instance is an EC2 instance together with the account owning its reservation
+type Instance struct {
+	ec2types.Instance
+	OwnerID string
+}
+
+// EC2Inventory lists the instances and volumes of one region once and shares
+// them between every analysis and blade scanning that region. Listings are
+// fetched lazily with the SDK paginators; a failed listing is retried on the
+// next call.
+type EC2Inventory struct {
+	client EC2API
+	region string
+
+	mu        sync.Mutex
+	instances []Instance
+	volumes   []ec2types.Volume
+	loaded    map[string]bool
+}
+
+// NewEC2Inventory creates an inventory for region backed by client
+func NewEC2Inventory(client EC2API, region string) *EC2Inventory {
+	return &EC2Inventory{
+		client: client,
+		region: region,
+		loaded: make(map[string]bool),
+	}
+}
+
+// Region returns the region the inventory covers
+func (inv *EC2Inventory) Region() string {
+	return inv.region
+}
+
+// Instances returns every instance of the region that is not terminated
+func (inv *EC2Inventory) Instances(ctx context.Context) ([]Instance, error) {
+	inv.mu.Lock()
+	defer inv.mu.Unlock()
+
+	if inv.loaded["instances"] {
+		return inv.instances, nil
+	}
+
+	paginator := ec2.NewDescribeInstancesPaginator(inv.client, &ec2.DescribeInstancesInput{
+		Filters: []ec2types.Filter{
+			{
+				Name:   aws.String("instance-state-name"),
+				Values: []string{"pending", "running", "stopping", "stopped"},
+			},
+		},
+	})
+
+	var instances []Instance
+	for paginator.HasMorePages() {
+		page, err := paginator.NextPage(ctx)
+		if err != nil {
+			return nil, fmt.Errorf("failed to describe instances in %s: %w", inv.region, err)
+		}
+		for _, reservation := range page.Reservations {
+			for _, instance := range reservation.Instances {
+				instances = append(instances, Instance{
+					Instance: instance,
+					OwnerID:  aws.ToString(reservation.OwnerId),
+				})
+			}
+		}
+	}
+
+	inv.instances = instances
+	inv.loaded["instances"] = true
+	return instances, nil
+}
+
+// InstancesInState returns the instances of the region in the given state
+func (inv *EC2Inventory) InstancesInState(ctx context.Context, state ec2types.InstanceStateName) ([]Instance, error) {
+	instances, err := inv.Instances(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	var filtered []Instance
+	for _, instance := range instances {
+		if instance.State != nil && instance.State.Name == state {
+			filtered = append(filtered, instance)
+		}
+	}
+	return filtered, nil
+}
+
+// Volumes returns every EBS volume of the region
+func (inv *EC2Inventory) Volumes(ctx context.Context) ([]ec2types.Volume, error) {
+	inv.mu.Lock()
+	defer inv.mu.Unlock()
+
+	if inv.loaded["volumes"] {
+		return inv.volumes, nil
+	}
+
+	paginator := ec2.NewDescribeVolumesPaginator(inv.client, &ec2.DescribeVolumesInput{})
+
+	var volumes []ec2types.Volume
+	for paginator.HasMorePages() {
+		page, err := paginator.NextPage(ctx)
+		if err != nil {
+			return nil, fmt.Errorf("failed to describe volumes in %s: %w", inv.region, err)
+		}
+		volumes = append(volumes, page.Volumes...)
+	}
+
+	inv.volumes = volumes
+	inv.loaded["volumes"] = true
+	return volumes, nil
+}
+
+// VolumesByInstance returns the volumes of the region keyed by the ID of each
+// instance they are attached to
+func (inv *EC2Inventory) VolumesByInstance(ctx context.Context) (map[string][]ec2types.Volume, error) {
+	volumes, err := inv.Volumes(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	byInstance := make(map[string][]ec2types.Volume)
+	for _, volume := range volumes {
+		for _, attachment := range volume.Attachments {
+			instanceID := aws.ToString(attachment.InstanceId)
+			if instanceID != "" {
+				byInstance[instanceID] = append(byInstance[instanceID], volume)
+			}
+		}
+	}
+	return byInstance, nil
+}
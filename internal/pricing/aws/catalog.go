@@ -0,0 +1,313 @@
+package aws
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+// Processor identifies the CPU vendor of an instance type
+type Processor string
+
+const (
+	ProcessorIntel    Processor = "intel"
+	ProcessorAMD      Processor = "amd"
+	ProcessorGraviton Processor = "graviton"
+	ProcessorOther    Processor = "other"
+)
+
+// UpgradeReason explains why an instance type is an upgrade candidate
+type UpgradeReason string
+
+const (
+	// UpgradeNewerGeneration is a newer generation with the same processor vendor
+	UpgradeNewerGeneration UpgradeReason = "newer-generation"
+	// UpgradeAMD is an AMD variant of an Intel family
+	UpgradeAMD UpgradeReason = "amd"
+	// UpgradeGraviton is an ARM-based Graviton variant, which requires
+	// arm64 builds of the workload
+	UpgradeGraviton UpgradeReason = "graviton"
+)
+
+// InstanceSpec describes an EC2 instance type as sold in a region
+type InstanceSpec struct {
+	InstanceType string
+	// Family is the part of the type before the size, e.g. "m6gd"
+	Family string
+	// Class is the leading letters of the family, e.g. "m"
+	Class string
+	// Generation is the generation number of the family, e.g. 6
+	Generation int
+	// Variant holds the family attributes that are not the processor
+	// vendor, e.g. "d" for local NVMe storage
+	Variant            string
+	Size               string
+	Category           string
+	Processor          Processor
+	Architecture       string
+	VCPU               int
+	MemoryGiB          float64
+	NetworkPerformance string
+	Storage            string
+	CurrentGeneration  bool
+	// PricePerHour is the Linux on-demand price with shared tenancy
+	PricePerHour float64
+}
+
+// UpgradeCandidate is an instance type that can replace another at a lower
+// price without reducing vCPU or memory
+type UpgradeCandidate struct {
+	Spec   InstanceSpec
+	Reason UpgradeReason
+}
+
+// InstanceCatalog holds the specs of every instance type sold in a region
+type InstanceCatalog struct {
+	region string
+	specs  map[string]InstanceSpec
+}
+
+// Region returns the region the catalog describes
+func (c *InstanceCatalog) Region() string {
+	return c.region
+}
+
+// Lookup returns the spec of an instance type
+func (c *InstanceCatalog) Lookup(instanceType string) (InstanceSpec, bool) {
+	spec, ok := c.specs[instanceType]
+	return spec, ok
+}
+
+// Types returns every instance type in the catalog, sorted
+func (c *InstanceCatalog) Types() []string {
+	instanceTypes := make([]string, 0, len(c.specs))
+	for instanceType := range c.specs {
+		instanceTypes = append(instanceTypes, instanceType)
+	}
+	sort.Strings(instanceTypes)
+	return instanceTypes
+}
+
+// UpgradeCandidates returns the current-generation types of the same class,
+// size and variant that have at least the vCPUs and memory of instanceType and
+// cost less. For each reason only the cheapest candidate is returned, and the
+// result is sorted by price.
+func (c *InstanceCatalog) UpgradeCandidates(instanceType string) []UpgradeCandidate {
+	current, ok := c.specs[instanceType]
+	if !ok || current.Class == "" || current.PricePerHour <= 0 {
+		return nil
+	}
+
+	best := make(map[UpgradeReason]InstanceSpec)
+	for _, spec := range c.specs {
+		if spec.InstanceType == current.InstanceType ||
+			!spec.CurrentGeneration ||
+			spec.Class != current.Class ||
+			spec.Variant != current.Variant ||
+			spec.Size != current.Size ||
+			spec.VCPU < current.VCPU ||
+			spec.MemoryGiB < current.MemoryGiB ||
+			spec.PricePerHour <= 0 ||
+			spec.PricePerHour >= current.PricePerHour {
+			continue
+		}
+
+		var reason UpgradeReason
+		switch {
+		case spec.Processor == ProcessorGraviton && current.Processor != ProcessorGraviton:
+			reason = UpgradeGraviton
+		case spec.Processor == ProcessorAMD && current.Processor == ProcessorIntel:
+			reason = UpgradeAMD
+		case spec.Processor == current.Processor && spec.Generation > current.Generation:
+			reason = UpgradeNewerGeneration
+		default:
+			continue
+		}
+
+		if existing, ok := best[reason]; !ok || betterCandidate(spec, existing) {
+			best[reason] = spec
+		}
+	}
+
+	candidates := make([]UpgradeCandidate, 0, len(best))
+	for reason, spec := range best {
+		candidates = append(candidates, UpgradeCandidate{Spec: spec, Reason: reason})
+	}
+	sort.Slice(candidates, func(i, j int) bool {
+		if candidates[i].Spec.PricePerHour != candidates[j].Spec.PricePerHour {
+			return candidates[i].Spec.PricePerHour < candidates[j].Spec.PricePerHour
+		}
+		return candidates[i].Spec.InstanceType < candidates[j].Spec.InstanceType
+	})
+	return candidates
+}
+
+// SimilarTypes returns the current-generation types of the same class, size
+// and variant as instanceType that run on the same architecture and have at
+// least its vCPUs and memory, regardless of price, sorted by name. The offer
+// files report both x86 and Graviton types as "64-bit", so Graviton types
+// are only similar to other Graviton types.
+func (c *InstanceCatalog) SimilarTypes(instanceType string) []InstanceSpec {
+	current, ok := c.specs[instanceType]
+	if !ok || current.Class == "" {
+		return nil
+	}
+
+	var similar []InstanceSpec
+	for _, spec := range c.specs {
+		if spec.InstanceType == current.InstanceType ||
+			!spec.CurrentGeneration ||
+			spec.Class != current.Class ||
+			spec.Variant != current.Variant ||
+			spec.Size != current.Size ||
+			spec.Architecture != current.Architecture ||
+			(spec.Processor == ProcessorGraviton) != (current.Processor == ProcessorGraviton) ||
+			spec.VCPU < current.VCPU ||
+			spec.MemoryGiB < current.MemoryGiB {
+			continue
+		}
+		similar = append(similar, spec)
+	}
+	sort.Slice(similar, func(i, j int) bool {
+		return similar[i].InstanceType < similar[j].InstanceType
+	})
+	return similar
+}
+
+// betterCandidate prefers the cheaper spec, then the newer generation
+func betterCandidate(spec, other InstanceSpec) bool {
+	if spec.PricePerHour != other.PricePerHour {
+		return spec.PricePerHour < other.PricePerHour
+	}
+	return spec.Generation > other.Generation
+}
+
+// InstanceCatalog returns the catalog of instance types sold in region. It
+// is built from the EC2 offer file on first use and cached.
+func (s *EC2PricingService) InstanceCatalog(ctx context.Context, region string) (*InstanceCatalog, error) {
+	if !s.IsRegionSupported(region) {
+		return nil, fmt.Errorf("region %s is not supported for pricing", region)
+	}
+
+	return s.catalogs.load(ctx, region, func() (*InstanceCatalog, error) {
+		index, err := s.offerIndex(ctx, EC2Service, region)
+		if err != nil {
+			return nil, err
+		}
+		return newInstanceCatalog(region, index), nil
+	})
+}
+
+// newInstanceCatalog builds the catalog of a region from its offer index
+func newInstanceCatalog(region string, index *offerIndex) *InstanceCatalog {
+	catalog := &InstanceCatalog{region: region, specs: make(map[string]InstanceSpec)}
+	for key, product := range index.instances {
+		attrs := product.attributes
+		if key != platformInstanceKey(attrs.InstanceType, PlatformLinux) {
+			continue
+		}
+
+		var price float64
+		if len(product.onDemand) > 0 {
+			price = product.onDemand[0].price
+		}
+
+		catalog.specs[attrs.InstanceType] = newInstanceSpec(attrs, price)
+	}
+	return catalog
+}
+
+// newInstanceSpec derives an instance spec from offer file attributes
+func newInstanceSpec(attrs ProductAttributes, price float64) InstanceSpec {
+	family, size, _ := strings.Cut(attrs.InstanceType, ".")
+	class, generation, variant, vendor := parseInstanceFamily(family)
+
+	processor := processorFromName(attrs.PhysicalProcessor)
+	if processor == ProcessorOther {
+		processor = vendor
+	}
+
+	vcpu, _ := strconv.Atoi(attrs.VCpu)
+
+	return InstanceSpec{
+		InstanceType:       attrs.InstanceType,
+		Family:             family,
+		Class:              class,
+		Generation:         generation,
+		Variant:            variant,
+		Size:               size,
+		Category:           attrs.InstanceFamily,
+		Processor:          processor,
+		Architecture:       attrs.ProcessorArchitecture,
+		VCPU:               vcpu,
+		MemoryGiB:          parseMemoryGiB(attrs.Memory),
+		NetworkPerformance: attrs.NetworkPerformance,
+		Storage:            attrs.Storage,
+		CurrentGeneration:  attrs.CurrentGeneration == "Yes",
+		PricePerHour:       price,
+	}
+}
+
+// parseInstanceFamily splits a family such as "m6gd" or "m7i-flex" into its
+// class ("m"), generation (6), non-processor variant ("d") and the processor
+// vendor implied by its attribute letters
+func parseInstanceFamily(family string) (string, int, string, Processor) {
+	i := strings.IndexFunc(family, unicode.IsDigit)
+	if i <= 0 {
+		return "", 0, "", ProcessorOther
+	}
+	class, rest := family[:i], family[i:]
+
+	j := strings.IndexFunc(rest, func(r rune) bool { return !unicode.IsDigit(r) })
+	if j < 0 {
+		j = len(rest)
+	}
+	generation, _ := strconv.Atoi(rest[:j])
+	attributes, suffix, _ := strings.Cut(rest[j:], "-")
+
+	vendor := ProcessorIntel
+	var variant strings.Builder
+	for _, attribute := range attributes {
+		switch attribute {
+		case 'a':
+			vendor = ProcessorAMD
+		case 'g':
+			vendor = ProcessorGraviton
+		case 'i':
+			vendor = ProcessorIntel
+		default:
+			variant.WriteRune(attribute)
+		}
+	}
+	if suffix != "" {
+		variant.WriteString("-" + suffix)
+	}
+
+	return class, generation, variant.String(), vendor
+}
+
+func processorFromName(name string) Processor {
+	switch lower := strings.ToLower(name); {
+	case strings.Contains(lower, "graviton"), strings.HasPrefix(lower, "aws "):
+		return ProcessorGraviton
+	case strings.Contains(lower, "amd"):
+		return ProcessorAMD
+	case strings.Contains(lower, "intel"):
+		return ProcessorIntel
+	default:
+		return ProcessorOther
+	}
+}
+
+// parseMemoryGiB parses memory attributes such as "16 GiB" or "0.5 GiB"
+func parseMemoryGiB(memory string) float64 {
+	value, _, _ := strings.Cut(strings.TrimSpace(memory), " ")
+	parsed, err := strconv.ParseFloat(strings.ReplaceAll(value, ",", ""), 64)
+	if err != nil {
+		return 0
+	}
+	return parsed
+}
@@ -0,0 +1,342 @@
+package aws
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// SavingsPlanService is the offer code of the Compute and EC2 Instance
+// Savings Plans rates
+const SavingsPlanService = "AWSComputeSavingsPlan"
+
+// CommitmentKind identifies a kind of pricing commitment
+type CommitmentKind string
+
+const (
+	CommitmentReservedInstance       CommitmentKind = "reserved-instance"
+	CommitmentComputeSavingsPlan     CommitmentKind = "compute-savings-plan"
+	CommitmentEC2InstanceSavingsPlan CommitmentKind = "ec2-instance-savings-plan"
+)
+
+// Commitment terms and purchase options as they appear in the offer files
+const (
+	Term1Year = "1yr"
+	Term3Year = "3yr"
+
+	NoUpfront      = "No Upfront"
+	PartialUpfront = "Partial Upfront"
+	AllUpfront     = "All Upfront"
+
+	OfferingClassStandard    = "standard"
+	OfferingClassConvertible = "convertible"
+)
+
+// termHours is the number of hours billed over a commitment term
+var termHours = map[string]float64{
+	Term1Year: 365 * 24,
+	Term3Year: 3 * 365 * 24,
+}
+
+// CommitmentTerms selects one commitment offering
+type CommitmentTerms struct {
+	Kind           CommitmentKind
+	Term           string
+	PurchaseOption string
+	// OfferingClass only applies to Reserved Instances
+	OfferingClass string
+}
+
+// CommitmentPrice is the price of running an instance under a commitment
+type CommitmentPrice struct {
+	CommitmentTerms
+	// Upfront is the amount paid when the commitment is purchased
+	Upfront float64
+	// Hourly is the recurring hourly charge
+	Hourly float64
+	// EffectiveHourly spreads the upfront amount over the term and adds it
+	// to the hourly charge, so it compares directly with on-demand prices
+	EffectiveHourly float64
+}
+
+// newCommitmentPrice completes a price from its upfront and hourly charges
+func newCommitmentPrice(terms CommitmentTerms, upfront, hourly float64) CommitmentPrice {
+	price := CommitmentPrice{CommitmentTerms: terms, Upfront: upfront, Hourly: hourly, EffectiveHourly: hourly}
+	if hours := termHours[terms.Term]; hours > 0 {
+		price.EffectiveHourly += upfront / hours
+	}
+	return price
+}
+
+// savingsPlanPrice converts a Savings Plans rate, which is already the
+// effective hourly price, into its upfront and recurring parts. All Upfront
+// plans pay the whole term in advance and Partial Upfront plans half of it.
+func savingsPlanPrice(terms CommitmentTerms, rate float64) CommitmentPrice {
+	total := rate * termHours[terms.Term]
+	var upfront float64
+	switch terms.PurchaseOption {
+	case AllUpfront:
+		upfront = total
+	case PartialUpfront:
+		upfront = total / 2
+	}
+
+	price := CommitmentPrice{CommitmentTerms: terms, Upfront: upfront, EffectiveHourly: rate}
+	if hours := termHours[terms.Term]; hours > 0 {
+		price.Hourly = (total - upfront) / hours
+	}
+	return price
+}
+
+// GetCommitmentPrices returns every Reserved Instance and Savings Plans price
+// of an instance type running platform
+func (s *EC2PricingService) GetCommitmentPrices(ctx context.Context, instanceType, region string, platform Platform) ([]CommitmentPrice, error) {
+	if !s.IsRegionSupported(region) {
+		return nil, fmt.Errorf("region %s is not supported for pricing", region)
+	}
+
+	index, err := s.offerIndex(ctx, EC2Service, region)
+	if err != nil {
+		return nil, err
+	}
+
+	product, ok := index.instance(platformInstanceKey(instanceType, platform))
+	if !ok {
+		return nil, fmt.Errorf("no pricing found for instance type %s running %s in region %s", instanceType, platform.Name, region)
+	}
+
+	prices := append([]CommitmentPrice(nil), product.reserved...)
+
+	rates, err := s.savingsPlanRates(ctx, region)
+	if err != nil {
+		return nil, err
+	}
+	prices = append(prices, rates[product.sku]...)
+
+	return prices, nil
+}
+
+// GetCommitmentPrice returns the price of an instance type running platform
+// under one commitment offering
+func (s *EC2PricingService) GetCommitmentPrice(ctx context.Context, instanceType, region string, platform Platform, terms CommitmentTerms) (CommitmentPrice, error) {
+	prices, err := s.GetCommitmentPrices(ctx, instanceType, region, platform)
+	if err != nil {
+		return CommitmentPrice{}, err
+	}
+
+	for _, price := range prices {
+		if price.CommitmentTerms == terms {
+			return price, nil
+		}
+	}
+
+	return CommitmentPrice{}, fmt.Errorf("no %s %s %s pricing found for instance type %s in region %s",
+		terms.Kind, terms.Term, terms.PurchaseOption, instanceType, region)
+}
+
+// savingsPlanRates returns the Savings Plans rates of a region keyed by the
+// on-demand SKU they discount, streaming the rate file on first use
+func (s *EC2PricingService) savingsPlanRates(ctx context.Context, region string) (map[string][]CommitmentPrice, error) {
+	return s.savingsPlans.load(ctx, region, func() (map[string][]CommitmentPrice, error) {
+		body, err := s.client.OpenSavingsPlanPricing(ctx, SavingsPlanService, region)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get Savings Plans pricing data: %w", err)
+		}
+		defer body.Close()
+
+		rates, err := decodeSavingsPlanRates(ctx, body)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse Savings Plans pricing data for %s: %w", region, err)
+		}
+		return rates, nil
+	})
+}
+
+// decodeReservedTerms decodes the reserved offers of one SKU
+func decodeReservedTerms(dec *json.Decoder, strs map[string]string) ([]CommitmentPrice, error) {
+	var terms map[string]struct {
+		PriceDimensions map[string]PriceDimension `json:"priceDimensions"`
+		TermAttributes  TermAttributes            `json:"termAttributes"`
+	}
+	if err := dec.Decode(&terms); err != nil {
+		return nil, err
+	}
+
+	var prices []CommitmentPrice
+	for _, term := range terms {
+		var upfront, hourly float64
+		for _, dimension := range term.PriceDimensions {
+			priceStr, ok := dimension.PricePerUnit["USD"]
+			if !ok {
+				continue
+			}
+			price, err := parsePrice(priceStr)
+			if err != nil {
+				return nil, err
+			}
+			switch dimension.Unit {
+			case "Quantity":
+				upfront = price
+			case "Hrs":
+				hourly = price
+			}
+		}
+
+		prices = append(prices, newCommitmentPrice(CommitmentTerms{
+			Kind:           CommitmentReservedInstance,
+			Term:           intern(strs, term.TermAttributes.LeaseContractLength),
+			PurchaseOption: intern(strs, term.TermAttributes.PurchaseOption),
+			OfferingClass:  intern(strs, term.TermAttributes.OfferingClass),
+		}, upfront, hourly))
+	}
+	return prices, nil
+}
+
+// decodeSavingsPlanRates streams a Savings Plans rate file, keeping the rates
+// of shared-tenancy EC2 usage keyed by the on-demand SKU they discount
+func decodeSavingsPlanRates(ctx context.Context, r io.Reader) (map[string][]CommitmentPrice, error) {
+	dec := json.NewDecoder(bufio.NewReaderSize(r, 1<<20))
+	strs := make(map[string]string)
+	plans := make(map[string]CommitmentTerms)
+
+	type savingsPlanRate struct {
+		sku  string
+		rate float64
+	}
+	planRates := make(map[string][]savingsPlanRate)
+
+	err := decodeObject(dec, func(key string) error {
+		switch key {
+		case "products":
+			return decodeArray(dec, func() error {
+				var product struct {
+					SKU           string `json:"sku"`
+					ProductFamily string `json:"productFamily"`
+					Attributes    struct {
+						PurchaseOption string `json:"purchaseOption"`
+						PurchaseTerm   string `json:"purchaseTerm"`
+					} `json:"attributes"`
+				}
+				if err := dec.Decode(&product); err != nil {
+					return err
+				}
+
+				var kind CommitmentKind
+				switch product.ProductFamily {
+				case "ComputeSavingsPlans":
+					kind = CommitmentComputeSavingsPlan
+				case "EC2InstanceSavingsPlans":
+					kind = CommitmentEC2InstanceSavingsPlan
+				default:
+					return nil
+				}
+				plans[product.SKU] = CommitmentTerms{
+					Kind:           kind,
+					Term:           intern(strs, product.Attributes.PurchaseTerm),
+					PurchaseOption: intern(strs, product.Attributes.PurchaseOption),
+				}
+				return nil
+			})
+		case "terms":
+			return decodeObject(dec, func(termType string) error {
+				if termType != "savingsPlan" {
+					return skipValue(dec)
+				}
+				return decodeArray(dec, func() error {
+					var planSKU string
+					var rates []savingsPlanRate
+					err := decodeObject(dec, func(field string) error {
+						switch field {
+						case "sku":
+							return dec.Decode(&planSKU)
+						case "rates":
+							return decodeArray(dec, func() error {
+								if err := ctx.Err(); err != nil {
+									return err
+								}
+								var rate struct {
+									DiscountedSku         string `json:"discountedSku"`
+									DiscountedUsageType   string `json:"discountedUsageType"`
+									DiscountedServiceCode string `json:"discountedServiceCode"`
+									DiscountedRate        struct {
+										Price    string `json:"price"`
+										Currency string `json:"currency"`
+									} `json:"discountedRate"`
+								}
+								if err := dec.Decode(&rate); err != nil {
+									return err
+								}
+								if rate.DiscountedServiceCode != EC2Service ||
+									rate.DiscountedRate.Currency != "USD" ||
+									!isBoxUsage(rate.DiscountedUsageType) {
+									return nil
+								}
+								price, err := parsePrice(rate.DiscountedRate.Price)
+								if err != nil {
+									return err
+								}
+								rates = append(rates, savingsPlanRate{sku: rate.DiscountedSku, rate: price})
+								return nil
+							})
+						default:
+							return skipValue(dec)
+						}
+					})
+					if err != nil {
+						return err
+					}
+					planRates[planSKU] = append(planRates[planSKU], rates...)
+					return nil
+				})
+			})
+		default:
+			return skipValue(dec)
+		}
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	// Plans may be listed after their rates, so they are joined last
+	bySKU := make(map[string][]CommitmentPrice)
+	for planSKU, rates := range planRates {
+		terms, ok := plans[planSKU]
+		if !ok {
+			continue
+		}
+		for _, rate := range rates {
+			bySKU[rate.sku] = append(bySKU[rate.sku], savingsPlanPrice(terms, rate.rate))
+		}
+	}
+	return bySKU, nil
+}
+
+// isBoxUsage reports whether a usage type is the shared-tenancy usage of an
+// instance type, "BoxUsage:<type>" after any region prefix, and not e.g. the
+// "HostBoxUsage:<family>" of Dedicated Hosts. us-east-1 usage types carry no
+// region prefix, and instance types such as u-6tb1.metal contain a "-", so
+// only the text before "-BoxUsage:" is a prefix.
+func isBoxUsage(usageType string) bool {
+	if i := strings.Index(usageType, "-BoxUsage:"); i >= 0 && !strings.Contains(usageType[:i], "-") {
+		usageType = usageType[i+1:]
+	}
+	instanceType, ok := strings.CutPrefix(usageType, "BoxUsage:")
+	return ok && instanceType != ""
+}
+
+// decodeArray reads a JSON array, calling fn with the decoder positioned at
+// each element. fn must consume exactly that element.
+func decodeArray(dec *json.Decoder, fn func() error) error {
+	if err := expectDelim(dec, '['); err != nil {
+		return err
+	}
+	for dec.More() {
+		if err := fn(); err != nil {
+			return err
+		}
+	}
+	return expectDelim(dec, ']')
+}
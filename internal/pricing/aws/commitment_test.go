@@ -0,0 +1,102 @@
+package aws
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestIsBoxUsage(t *testing.T) {
+	tests := []struct {
+		usageType string
+		want      bool
+	}{
+		{"BoxUsage:t3.micro", true},
+		{"EUW1-BoxUsage:m5.large", true},
+		{"USE2-BoxUsage:u-6tb1.metal", true},
+		{"BoxUsage:u-6tb1.metal", true},
+		{"HostBoxUsage:u-6tb1", false},
+		{"EUW1-HostBoxUsage:u-6tb1", false},
+		{"EUW1-HostBoxUsage:m5", false},
+		{"HostBoxUsage:m5", false},
+		{"EUW1-DedicatedUsage:t3.micro", false},
+		{"EUW1-UnusedBox:t3.micro", false},
+		{"EUW1-BoxUsage", false},
+		{"EUW1-BoxUsage:", false},
+	}
+
+	for _, tt := range tests {
+		if got := isBoxUsage(tt.usageType); got != tt.want {
+			t.Errorf("isBoxUsage(%q) = %v, want %v", tt.usageType, got, tt.want)
+		}
+	}
+}
+
+func TestDecodeSavingsPlanRates(t *testing.T) {
+	file, err := os.Open(filepath.Join(testSnapshot, SavingsPlanService, testRegion+".json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer file.Close()
+
+	rates, err := decodeSavingsPlanRates(context.Background(), file)
+	if err != nil {
+		t.Fatalf("decodeSavingsPlanRates: %v", err)
+	}
+
+	// Only the shared-tenancy rates of EC2 instances are kept
+	want := map[string]int{"T3MICROLINUX": 2, "M5LARGELINUXB": 1}
+	if len(rates) != len(want) {
+		t.Errorf("rates of %d SKUs, want %d: %v", len(rates), len(want), rates)
+	}
+	for sku, n := range want {
+		if len(rates[sku]) != n {
+			t.Errorf("%s has %d rates, want %d", sku, len(rates[sku]), n)
+		}
+	}
+}
+
+func TestGetCommitmentPrice(t *testing.T) {
+	service := newTestPricingService(t)
+
+	tests := []struct {
+		terms       CommitmentTerms
+		wantUpfront float64
+		wantHourly  float64
+	}{
+		{
+			terms:      CommitmentTerms{Kind: CommitmentReservedInstance, Term: Term1Year, PurchaseOption: NoUpfront, OfferingClass: OfferingClassStandard},
+			wantHourly: 0.0072,
+		},
+		{
+			terms:       CommitmentTerms{Kind: CommitmentReservedInstance, Term: Term1Year, PurchaseOption: AllUpfront, OfferingClass: OfferingClassStandard},
+			wantUpfront: 62,
+		},
+		{
+			terms:      CommitmentTerms{Kind: CommitmentComputeSavingsPlan, Term: Term1Year, PurchaseOption: NoUpfront},
+			wantHourly: 0.0081,
+		},
+		{
+			terms:       CommitmentTerms{Kind: CommitmentEC2InstanceSavingsPlan, Term: Term1Year, PurchaseOption: AllUpfront},
+			wantUpfront: 0.0070 * 365 * 24,
+		},
+	}
+
+	for _, tt := range tests {
+		price, err := service.GetCommitmentPrice(context.Background(), "t3.micro", testRegion, PlatformLinux, tt.terms)
+		if err != nil {
+			t.Errorf("GetCommitmentPrice(%+v): %v", tt.terms, err)
+			continue
+		}
+		if !approxEqual(price.Upfront, tt.wantUpfront) || !approxEqual(price.Hourly, tt.wantHourly) {
+			t.Errorf("GetCommitmentPrice(%+v) = upfront %v, hourly %v, want %v, %v",
+				tt.terms, price.Upfront, price.Hourly, tt.wantUpfront, tt.wantHourly)
+		}
+	}
+}
+
+func approxEqual(a, b float64) bool {
+	const epsilon = 1e-9
+	return a-b < epsilon && b-a < epsilon
+}
@@ -0,0 +1,239 @@
+package aws
+
+import (
+	"context"
+	"fmt"
+)
+
+// Usage types of standard and archive tier snapshot storage in the AmazonEC2
+// offer file, without their region prefix
+const (
+	snapshotUsage        = "EBS:SnapshotUsage"
+	snapshotArchiveUsage = "EBS:SnapshotArchiveStorage"
+)
+
+// gp3 volumes include a baseline of IOPS and throughput in their storage
+// price; only what is provisioned above it is billed
+const (
+	GP3BaselineIOPS       = 3000
+	GP3BaselineThroughput = 125
+)
+
+// volumeStorageUsage maps each volume type to the usage type of its storage
+// SKU, billed per GB-month
+var volumeStorageUsage = map[string]string{
+	"gp2":      "EBS:VolumeUsage.gp2",
+	"gp3":      "EBS:VolumeUsage.gp3",
+	"io1":      "EBS:VolumeUsage.piops",
+	"io2":      "EBS:VolumeUsage.io2",
+	"st1":      "EBS:VolumeUsage.st1",
+	"sc1":      "EBS:VolumeUsage.sc1",
+	"standard": "EBS:VolumeUsage",
+}
+
+// volumeIOPSUsage maps the volume types billed for provisioned IOPS to the
+// usage type of their IOPS SKU, or of the first tier of tiered types
+var volumeIOPSUsage = map[string]string{
+	"io1": "EBS:VolumeP-IOPS.piops",
+	"io2": "EBS:VolumeP-IOPS.io2",
+	"gp3": "EBS:VolumeP-IOPS.gp3",
+}
+
+// iopsTierUsage is the usage type of an IOPS price tier and the provisioned
+// IOPS above which it applies
+type iopsTierUsage struct {
+	fromIOPS  int
+	usageType string
+}
+
+// volumeIOPSTierUsage lists the IOPS tiers after the first of tiered types
+var volumeIOPSTierUsage = map[string][]iopsTierUsage{
+	"io2": {
+		{fromIOPS: 32000, usageType: "EBS:VolumeP-IOPS.io2.tier2"},
+		{fromIOPS: 64000, usageType: "EBS:VolumeP-IOPS.io2.tier3"},
+	},
+}
+
+// volumeThroughputUsage maps the volume types billed for provisioned
+// throughput to the usage type of their throughput SKU
+var volumeThroughputUsage = map[string]string{
+	"gp3": "EBS:VolumeP-Throughput.gp3",
+}
+
+// GetVolumeRates retrieves the storage, IOPS and throughput prices of a
+// volume type. gp3 includes its baseline IOPS and throughput; every other
+// type is billed for all the IOPS it provisions.
+func (s *EC2PricingService) GetVolumeRates(ctx context.Context, volumeType, region string) (Volume, error) {
+	var rates Volume
+	var err error
+	if rates.PricePerGBMonth, err = s.GetVolumePrice(ctx, volumeType, region); err != nil {
+		return Volume{}, err
+	}
+	if rates.PricePerIOPSMonth, err = s.GetVolumeIOPSPrice(ctx, volumeType, region); err != nil {
+		return Volume{}, err
+	}
+	if rates.PricePerMiBpsMonth, err = s.GetVolumeThroughputPrice(ctx, volumeType, region); err != nil {
+		return Volume{}, err
+	}
+	if volumeType == "gp3" {
+		rates.IOPSIncluded = GP3BaselineIOPS
+		rates.ThroughputIncluded = GP3BaselineThroughput
+	}
+
+	if _, ok := volumeIOPSTierUsage[volumeType]; ok {
+		index, err := s.offerIndex(ctx, EC2Service, region)
+		if err != nil {
+			return Volume{}, err
+		}
+		rates.IOPSTiers = index.iopsTiers(volumeType)
+	}
+	return rates, nil
+}
+
+// GetVolumeIOPSPrice retrieves the monthly price of one provisioned IOPS of
+// a volume type. Volume types without an IOPS charge cost nothing.
+func (s *EC2PricingService) GetVolumeIOPSPrice(ctx context.Context, volumeType, region string) (float64, error) {
+	usageType, ok := volumeIOPSUsage[volumeType]
+	if !ok {
+		return 0, nil
+	}
+
+	index, err := s.offerIndex(ctx, EC2Service, region)
+	if err != nil {
+		return 0, err
+	}
+
+	if price, ok := index.ebsPrice(usageType, "IOPS-Mo"); ok {
+		return price, nil
+	}
+	return 0, fmt.Errorf("no IOPS pricing found for volume type %s in region %s", volumeType, region)
+}
+
+// GetVolumeThroughputPrice retrieves the monthly price of one provisioned
+// MiB/s of a volume type. Volume types without a throughput charge cost
+// nothing.
+func (s *EC2PricingService) GetVolumeThroughputPrice(ctx context.Context, volumeType, region string) (float64, error) {
+	usageType, ok := volumeThroughputUsage[volumeType]
+	if !ok {
+		return 0, nil
+	}
+
+	index, err := s.offerIndex(ctx, EC2Service, region)
+	if err != nil {
+		return 0, err
+	}
+
+	if price, ok := index.throughputPrice(usageType); ok {
+		return price, nil
+	}
+	return 0, fmt.Errorf("no throughput pricing found for volume type %s in region %s", volumeType, region)
+}
+
+// GetSnapshotPrice retrieves the monthly price of one GB of standard tier
+// EBS snapshot storage
+func (s *EC2PricingService) GetSnapshotPrice(ctx context.Context, region string) (float64, error) {
+	index, err := s.offerIndex(ctx, EC2Service, region)
+	if err != nil {
+		return 0, err
+	}
+
+	if price, ok := index.ebsPrice(snapshotUsage, "GB-Mo"); ok {
+		return price, nil
+	}
+	return 0, fmt.Errorf("no snapshot pricing found in region %s", region)
+}
+
+// GetSnapshotArchivePrice retrieves the monthly price of one GB of archive
+// tier EBS snapshot storage
+func (s *EC2PricingService) GetSnapshotArchivePrice(ctx context.Context, region string) (float64, error) {
+	index, err := s.offerIndex(ctx, EC2Service, region)
+	if err != nil {
+		return 0, err
+	}
+
+	if price, ok := index.ebsPrice(snapshotArchiveUsage, "GB-Mo"); ok {
+		return price, nil
+	}
+	return 0, fmt.Errorf("no snapshot archive pricing found in region %s", region)
+}
+
+// iopsTiers returns the IOPS tiers after the first of a volume type found in
+// the offer
+func (x *offerIndex) iopsTiers(volumeType string) []IOPSTier {
+	var tiers []IOPSTier
+	for _, tier := range volumeIOPSTierUsage[volumeType] {
+		if price, ok := x.ebsPrice(tier.usageType, "IOPS-Mo"); ok {
+			tiers = append(tiers, IOPSTier{FromIOPS: tier.fromIOPS, PricePerIOPSMonth: price})
+		}
+	}
+	return tiers
+}
+
+// throughputPrice returns the monthly price of one MiB/s of a throughput
+// SKU, which the offer files list per GiB/s
+func (x *offerIndex) throughputPrice(usageType string) (float64, bool) {
+	if price, ok := x.ebsPrice(usageType, "GiBps-mo"); ok {
+		return price / 1024, true
+	}
+	return x.ebsPrice(usageType, "MiBps-Mo")
+}
+
+// VolumeSpec is the provisioned configuration of an EBS volume
+type VolumeSpec struct {
+	VolumeType string
+	SizeGB     int
+	// IOPS and Throughput (MiB/s) are the provisioned performance. They are
+	// ignored for types that do not bill them, such as gp2.
+	IOPS       int
+	Throughput int
+}
+
+// VolumeCost is the monthly cost of a volume broken down by charge
+type VolumeCost struct {
+	Base       float64
+	Storage    float64
+	IOPS       float64
+	Throughput float64
+}
+
+// Total returns the monthly cost of the volume
+func (c VolumeCost) Total() float64 {
+	return c.Base + c.Storage + c.IOPS + c.Throughput
+}
+
+// MonthlyCost prices a volume with the rates of its type. Only the IOPS and
+// throughput provisioned above the amounts included with the type are
+// billed, so a gp3 volume at its baseline costs its storage alone while io1
+// and io2 pay for every provisioned IOPS.
+func (v Volume) MonthlyCost(spec VolumeSpec) VolumeCost {
+	return VolumeCost{
+		Base:       v.BasePricePerMonth,
+		Storage:    v.PricePerGBMonth * float64(spec.SizeGB),
+		IOPS:       v.iopsCost(max(spec.IOPS-v.IOPSIncluded, 0)),
+		Throughput: v.PricePerMiBpsMonth * float64(max(spec.Throughput-v.ThroughputIncluded, 0)),
+	}
+}
+
+// iopsCost prices billable IOPS, each tier charging for the IOPS between
+// its start and the next tier's
+func (v Volume) iopsCost(iops int) float64 {
+	var cost float64
+	price, from := v.PricePerIOPSMonth, 0
+	for _, tier := range v.IOPSTiers {
+		if iops <= tier.FromIOPS {
+			break
+		}
+		cost += price * float64(tier.FromIOPS-from)
+		price, from = tier.PricePerIOPSMonth, tier.FromIOPS
+	}
+	return cost + price*float64(iops-from)
+}
+
+// GetVolumeCost returns the monthly cost of a volume in a region
+func GetVolumeCost(ctx context.Context, prices PriceProvider, spec VolumeSpec, region string) (VolumeCost, error) {
+	rates, err := prices.GetVolumeRates(ctx, spec.VolumeType, region)
+	if err != nil {
+		return VolumeCost{}, err
+	}
+	return rates.MonthlyCost(spec), nil
+}
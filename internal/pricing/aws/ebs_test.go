@@ -0,0 +1,93 @@
+package aws
+
+import (
+	"bytes"
+	"context"
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+var update = flag.Bool("update", false, "rewrite the golden files in testdata")
+
+func TestGetVolumeCostGolden(t *testing.T) {
+	specs := []VolumeSpec{
+		{VolumeType: "gp2", SizeGB: 100},
+		// gp2 performance scales with size and is never billed
+		{VolumeType: "gp2", SizeGB: 100, IOPS: 3000},
+		{VolumeType: "gp3", SizeGB: 500, IOPS: 3000, Throughput: 125},
+		{VolumeType: "gp3", SizeGB: 500, IOPS: 6000, Throughput: 250},
+		{VolumeType: "gp3", SizeGB: 1000, IOPS: 16000, Throughput: 1000},
+		{VolumeType: "io1", SizeGB: 100, IOPS: 5000},
+		{VolumeType: "io2", SizeGB: 100, IOPS: 5000},
+		// io2 IOPS above 32000 and 64000 are billed at lower tiers
+		{VolumeType: "io2", SizeGB: 100, IOPS: 32000},
+		{VolumeType: "io2", SizeGB: 100, IOPS: 40000},
+		{VolumeType: "io2", SizeGB: 2000, IOPS: 80000},
+		{VolumeType: "st1", SizeGB: 500},
+		{VolumeType: "st1", SizeGB: 500, Throughput: 250},
+		{VolumeType: "sc1", SizeGB: 500},
+		{VolumeType: "standard", SizeGB: 100},
+	}
+
+	service := newTestPricingService(t)
+	var got bytes.Buffer
+	for _, spec := range specs {
+		cost, err := GetVolumeCost(context.Background(), service, spec, testRegion)
+		if err != nil {
+			t.Fatalf("GetVolumeCost(%+v): %v", spec, err)
+		}
+		fmt.Fprintf(&got, "%-8s %5d GB %6d IOPS %5d MiB/s  storage %9.4f  iops %9.4f  throughput %8.4f  total %9.4f\n",
+			spec.VolumeType, spec.SizeGB, spec.IOPS, spec.Throughput,
+			cost.Storage, cost.IOPS, cost.Throughput, cost.Total())
+	}
+
+	golden := filepath.Join("testdata", "volume_cost.golden")
+	if *update {
+		if err := os.WriteFile(golden, got.Bytes(), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	want, err := os.ReadFile(golden)
+	if err != nil {
+		t.Fatalf("%v (run with -update to create it)", err)
+	}
+	if !bytes.Equal(got.Bytes(), want) {
+		t.Errorf("volume costs differ from %s:\ngot:\n%s\nwant:\n%s", golden, got.Bytes(), want)
+	}
+}
+
+func TestGetVolumeCostUnknownType(t *testing.T) {
+	service := newTestPricingService(t)
+	if _, err := GetVolumeCost(context.Background(), service, VolumeSpec{VolumeType: "gp4", SizeGB: 100}, testRegion); err == nil {
+		t.Error("GetVolumeCost of an unknown volume type succeeded")
+	}
+}
+
+func TestVolumeMonthlyCost(t *testing.T) {
+	rates := Volume{
+		PricePerGBMonth:    0.1,
+		BasePricePerMonth:  1,
+		IOPSIncluded:       3000,
+		ThroughputIncluded: 125,
+		PricePerIOPSMonth:  0.01,
+		PricePerMiBpsMonth: 0.05,
+	}
+
+	tests := []struct {
+		spec VolumeSpec
+		want VolumeCost
+	}{
+		{VolumeSpec{SizeGB: 10}, VolumeCost{Base: 1, Storage: 1}},
+		{VolumeSpec{SizeGB: 10, IOPS: 3000, Throughput: 125}, VolumeCost{Base: 1, Storage: 1}},
+		{VolumeSpec{SizeGB: 10, IOPS: 4000, Throughput: 225}, VolumeCost{Base: 1, Storage: 1, IOPS: 10, Throughput: 5}},
+	}
+
+	for _, tt := range tests {
+		if got := rates.MonthlyCost(tt.spec); got != tt.want {
+			t.Errorf("MonthlyCost(%+v) = %+v, want %+v", tt.spec, got, tt.want)
+		}
+	}
+}
@@ -1,34 +1,56 @@
 package aws
 
 import (
-    "encoding/json"
+    "context"
     "fmt"
-    "strings"
 
     "github.com/yourusername/cloudshaver/internal/pricing/client"
 )
 
+// EC2Service is the offer code of EC2, whose offer files also price EBS
+const EC2Service = "AmazonEC2"
+
+// Attribute values of the on-demand SKUs of instances on shared hardware.
+// Other SKUs of the same instance type price dedicated tenancy and capacity
+// reservations.
 const (
-    EC2Service = "AmazonEC2"
-    EBSService = "AmazonEBS"
+    sharedTenancy = "Shared"
+    capacityUsed  = "Used"
 )
 
 type EC2PricingService struct {
-    client *client.PricingClient
+    client client.Source
     supportedRegions map[string]bool
+
+    // offers holds the decoded offer files, keyed by service and region
+    offers keyedLoader[*offerIndex]
+    // savingsPlans holds the Savings Plans rates of each region keyed by
+    // the on-demand SKU they discount
+    savingsPlans keyedLoader[map[string][]CommitmentPrice]
+    // catalogs holds the instance catalog of each region
+    catalogs keyedLoader[*InstanceCatalog]
 }
 
 type ProductAttributes struct {
     InstanceType     string `json:"instanceType"`
+    InstanceFamily   string `json:"instanceFamily"`
+    CurrentGeneration string `json:"currentGeneration"`
     VCpu            string `json:"vcpu"`
     Memory          string `json:"memory"`
     Storage         string `json:"storage"`
+    NetworkPerformance string `json:"networkPerformance"`
+    PhysicalProcessor string `json:"physicalProcessor"`
+    ProcessorArchitecture string `json:"processorArchitecture"`
+    Tenancy         string `json:"tenancy"`
     OperatingSystem string `json:"operatingSystem"`
     PreInstalledSw  string `json:"preInstalledSw"`
+    LicenseModel    string `json:"licenseModel"`
     UsageType       string `json:"usageType"`
     Operation       string `json:"operation"`
     CapacityStatus  string `json:"capacitystatus"`
     VolumeType      string `json:"volumeType"`
+    VolumeAPIName   string `json:"volumeApiName"`
+    Location        string `json:"location"`
 }
 
 type PriceDimension struct {
@@ -43,25 +65,31 @@ type TermAttributes struct {
     OfferingClass      string `json:"OfferingClass"`
 }
 
-// NewEC2PricingService creates a new EC2 pricing service
-func NewEC2PricingService(region string) (*EC2PricingService, error) {
-    client := client.NewPricingClient(region)
-    
+// NewEC2PricingService creates a new EC2 pricing service reading price lists
+// from source. A nil source downloads them from the bulk pricing endpoint
+// with the default disk cache.
+func NewEC2PricingService(ctx context.Context, source client.Source) (*EC2PricingService, error) {
+    if source == nil {
+        var err error
+        source, err = NewSource(SourceOptions{})
+        if err != nil {
+            return nil, err
+        }
+    }
+
     // Get list of supported regions
-    index, err := client.GetServiceIndex()
+    regions, err := source.ServiceRegions(ctx, EC2Service)
     if err != nil {
-        return nil, fmt.Errorf("failed to get service index: %w", err)
+        return nil, fmt.Errorf("failed to get service regions: %w", err)
     }
 
     supportedRegions := make(map[string]bool)
-    if ec2Offer, ok := index.Offers[EC2Service]; ok {
-        for region := range ec2Offer.Regions {
-            supportedRegions[region] = true
-        }
+    for _, region := range regions {
+        supportedRegions[region] = true
     }
 
     return &EC2PricingService{
-        client: client,
+        client: source,
         supportedRegions: supportedRegions,
     }, nil
 }
@@ -71,136 +99,43 @@ func (s *EC2PricingService) IsRegionSupported(region string) bool {
     return s.supportedRegions[region]
 }
 
-// GetInstancePrice retrieves the price for a specific EC2 instance type
-func (s *EC2PricingService) GetInstancePrice(instanceType, region string) (float64, error) {
+// GetInstancePrice retrieves the on-demand price of an EC2 instance type
+// running platform, so licensed operating systems are priced with their license
+func (s *EC2PricingService) GetInstancePrice(ctx context.Context, instanceType, region string, platform Platform) (float64, error) {
     if !s.IsRegionSupported(region) {
         return 0, fmt.Errorf("region %s is not supported for pricing", region)
     }
 
-    data, err := s.client.GetServicePricing(EC2Service, region)
-    if err != nil {
-        return 0, fmt.Errorf("failed to get EC2 pricing data: %w", err)
-    }
-
-    // Parse and process pricing data
-    var pricing struct {
-        Products map[string]struct {
-            Attributes ProductAttributes `json:"attributes"`
-        } `json:"products"`
-        Terms struct {
-            OnDemand map[string]map[string]struct {
-                PriceDimensions map[string]PriceDimension `json:"priceDimensions"`
-            } `json:"OnDemand"`
-        } `json:"terms"`
-    }
-
-    if err := json.Unmarshal(data, &pricing); err != nil {
-        return 0, fmt.Errorf("failed to parse pricing data: %w", err)
-    }
-
-    // Find matching instance type
-    for _, product := range pricing.Products {
-        if product.Attributes.InstanceType == instanceType &&
-           product.Attributes.OperatingSystem == "Linux" &&
-           product.Attributes.PreInstalledSw == "NA" {
-            // Find corresponding price
-            for _, term := range pricing.Terms.OnDemand {
-                for _, price := range term {
-                    for _, dimension := range price.PriceDimensions {
-                        if priceStr, ok := dimension.PricePerUnit["USD"]; ok {
-                            return parsePrice(priceStr)
-                        }
-                    }
-                }
-            }
-        }
-    }
-
-    return 0, fmt.Errorf("no pricing found for instance type %s in region %s", instanceType, region)
-}
-
-// GetVolumePrice retrieves the price for a specific EBS volume type
-func (s *EC2PricingService) GetVolumePrice(volumeType, region string) (float64, error) {
-    data, err := s.client.GetServicePricing(EBSService, region)
+    index, err := s.offerIndex(ctx, EC2Service, region)
     if err != nil {
         return 0, err
     }
 
-    var pricing struct {
-        Products map[string]struct {
-            Attributes ProductAttributes `json:"attributes"`
-        } `json:"products"`
-        Terms struct {
-            OnDemand map[string]map[string]struct {
-                PriceDimensions map[string]PriceDimension `json:"priceDimensions"`
-            } `json:"OnDemand"`
-        } `json:"terms"`
-    }
-
-    if err := json.Unmarshal(data, &pricing); err != nil {
-        return 0, fmt.Errorf("failed to parse EBS pricing: %v", err)
+    product, ok := index.instance(platformInstanceKey(instanceType, platform))
+    if ok && len(product.onDemand) > 0 {
+        return product.onDemand[0].price, nil
     }
 
-    // Find the product ID for the volume type
-    var productID string
-    for id, product := range pricing.Products {
-        attrs := product.Attributes
-        if strings.EqualFold(attrs.VolumeType, volumeType) {
-            productID = id
-            break
-        }
-    }
-
-    if productID == "" {
-        return 0, fmt.Errorf("volume type %s not found in pricing data", volumeType)
-    }
-
-    // Find the price for the product
-    for _, term := range pricing.Terms.OnDemand[productID] {
-        for _, dimension := range term.PriceDimensions {
-            if dimension.Unit == "GB-Mo" {
-                for _, price := range dimension.PricePerUnit {
-                    return parsePrice(price)
-                }
-            }
-        }
-    }
-
-    return 0, fmt.Errorf("no pricing found for volume type %s", volumeType)
+    return 0, fmt.Errorf("no pricing found for instance type %s running %s in region %s", instanceType, platform.Name, region)
 }
 
-// CalculateInstanceSavings calculates potential savings for an EC2 instance
-func (s *EC2PricingService) CalculateInstanceSavings(currentType, targetType, region string) (float64, error) {
-    currentPrice, err := s.GetInstancePrice(currentType, region)
-    if err != nil {
-        return 0, fmt.Errorf("failed to get current instance price: %v", err)
-    }
-
-    targetPrice, err := s.GetInstancePrice(targetType, region)
-    if err != nil {
-        return 0, fmt.Errorf("failed to get target instance price: %v", err)
+// GetVolumePrice retrieves the monthly price of one GB of an EBS volume
+// type from its storage SKU
+func (s *EC2PricingService) GetVolumePrice(ctx context.Context, volumeType, region string) (float64, error) {
+    usageType, ok := volumeStorageUsage[volumeType]
+    if !ok {
+        return 0, fmt.Errorf("volume type %s not found in pricing data", volumeType)
     }
 
-    hourlyDiff := currentPrice - targetPrice
-    monthlySavings := hourlyDiff * 730 // Average hours in a month
-
-    return monthlySavings, nil
-}
-
-// CalculateVolumeSavings calculates potential savings for an EBS volume
-func (s *EC2PricingService) CalculateVolumeSavings(currentType, targetType string, sizeGB int, region string) (float64, error) {
-    currentPrice, err := s.GetVolumePrice(currentType, region)
+    index, err := s.offerIndex(ctx, EC2Service, region)
     if err != nil {
-        return 0, fmt.Errorf("failed to get current volume price: %v", err)
+        return 0, err
     }
 
-    targetPrice, err := s.GetVolumePrice(targetType, region)
-    if err != nil {
-        return 0, fmt.Errorf("failed to get target volume price: %v", err)
+    if price, ok := index.ebsPrice(usageType, "GB-Mo"); ok {
+        return price, nil
     }
-
-    monthlySavings := float64(sizeGB) * (currentPrice - targetPrice)
-    return monthlySavings, nil
+    return 0, fmt.Errorf("no pricing found for volume type %s in region %s", volumeType, region)
 }
 
 func parsePrice(price string) (float64, error) {
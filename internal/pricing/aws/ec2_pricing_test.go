@@ -0,0 +1,146 @@
+package aws
+
+import (
+	"bytes"
+	"context"
+	"testing"
+)
+
+func TestProductInstanceKey(t *testing.T) {
+	index, err := decodeOffer(context.Background(), bytes.NewReader(readTestOffer(t)))
+	if err != nil {
+		t.Fatalf("decodeOffer: %v", err)
+	}
+
+	tests := []struct {
+		sku  string
+		want instanceKey
+	}{
+		{"T3MICROLINUX", instanceKey{"t3.micro", sharedTenancy, capacityUsed, LicenseIncluded, OperationLinux}},
+		{"T3MICROWINDOWS", instanceKey{"t3.micro", sharedTenancy, capacityUsed, LicenseIncluded, OperationWindows}},
+		{"T3MICROBYOL", instanceKey{"t3.micro", sharedTenancy, capacityUsed, LicenseBYOL, OperationWindowsBYOL}},
+		{"T3MICRODEDICATED", instanceKey{"t3.micro", "Dedicated", capacityUsed, LicenseIncluded, OperationLinux}},
+		{"T3MICROALLOCATED", instanceKey{"t3.micro", sharedTenancy, "AllocatedCapacityReservation", LicenseIncluded, OperationLinux}},
+		{"M5LARGESQLSTD", instanceKey{"m5.large", sharedTenancy, capacityUsed, LicenseIncluded, OperationWindowsSQLStd}},
+	}
+
+	for _, tt := range tests {
+		product, ok := index.products[tt.sku]
+		if !ok {
+			t.Errorf("%s not in the offer", tt.sku)
+			continue
+		}
+		if got := productInstanceKey(product.attributes); got != tt.want {
+			t.Errorf("productInstanceKey(%s) = %+v, want %+v", tt.sku, got, tt.want)
+		}
+	}
+}
+
+func TestPlatformInstanceKeySelectsSKU(t *testing.T) {
+	index, err := decodeOffer(context.Background(), bytes.NewReader(readTestOffer(t)))
+	if err != nil {
+		t.Fatalf("decodeOffer: %v", err)
+	}
+
+	tests := []struct {
+		name           string
+		instanceType   string
+		platform       Platform
+		wantSKU        string
+		wantOnDemandHr float64
+	}{
+		{
+			name:           "linux skips dedicated and capacity reservation SKUs",
+			instanceType:   "t3.micro",
+			platform:       PlatformLinux,
+			wantSKU:        "T3MICROLINUX",
+			wantOnDemandHr: 0.0114,
+		},
+		{
+			name:           "windows license included",
+			instanceType:   "t3.micro",
+			platform:       InstancePlatform("Windows", OperationWindows),
+			wantSKU:        "T3MICROWINDOWS",
+			wantOnDemandHr: 0.0206,
+		},
+		{
+			name:           "windows BYOL",
+			instanceType:   "t3.micro",
+			platform:       InstancePlatform("Windows BYOL", OperationWindowsBYOL),
+			wantSKU:        "T3MICROBYOL",
+			wantOnDemandHr: 0.0115,
+		},
+		{
+			name:           "red hat",
+			instanceType:   "t3.micro",
+			platform:       InstancePlatform("Red Hat Enterprise Linux", OperationRHEL),
+			wantSKU:        "T3MICRORHEL",
+			wantOnDemandHr: 0.0714,
+		},
+		{
+			name:           "windows with SQL Server Standard",
+			instanceType:   "m5.large",
+			platform:       InstancePlatform("Windows with SQL Server Standard", OperationWindowsSQLStd),
+			wantSKU:        "M5LARGESQLSTD",
+			wantOnDemandHr: 0.587,
+		},
+		{
+			name:           "duplicate keys keep the lowest SKU",
+			instanceType:   "m5.large",
+			platform:       PlatformLinux,
+			wantSKU:        "M5LARGELINUXB",
+			wantOnDemandHr: 0.107,
+		},
+		{
+			name:           "bare metal",
+			instanceType:   "m5.metal",
+			platform:       PlatformLinux,
+			wantSKU:        "M5METALLINUX",
+			wantOnDemandHr: 5.136,
+		},
+		{
+			name:         "no SKU for the platform",
+			instanceType: "m5.metal",
+			platform:     InstancePlatform("SUSE Linux", OperationSUSE),
+		},
+		{
+			name:         "dedicated host is not an instance",
+			instanceType: "m5",
+			platform:     PlatformLinux,
+		},
+	}
+
+	service := newTestPricingService(t)
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			product, ok := index.instance(platformInstanceKey(tt.instanceType, tt.platform))
+			price, err := service.GetInstancePrice(context.Background(), tt.instanceType, testRegion, tt.platform)
+
+			if tt.wantSKU == "" {
+				if ok {
+					t.Errorf("matched %s, want no SKU", product.sku)
+				}
+				if err == nil {
+					t.Errorf("GetInstancePrice = %v, want an error", price)
+				}
+				return
+			}
+			if !ok {
+				t.Fatalf("no SKU matched, want %s", tt.wantSKU)
+			}
+			if product.sku != tt.wantSKU {
+				t.Errorf("SKU = %s, want %s", product.sku, tt.wantSKU)
+			}
+			if err != nil || price != tt.wantOnDemandHr {
+				t.Errorf("GetInstancePrice = %v, %v, want %v", price, err, tt.wantOnDemandHr)
+			}
+		})
+	}
+}
+
+func TestGetInstancePriceUnsupportedRegion(t *testing.T) {
+	service := newTestPricingService(t)
+	if _, err := service.GetInstancePrice(context.Background(), "t3.micro", "us-east-1", PlatformLinux); err == nil {
+		t.Error("GetInstancePrice in a region without an offer file succeeded")
+	}
+}
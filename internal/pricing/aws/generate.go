@@ -0,0 +1,244 @@
+package aws
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strconv"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	"github.com/yourusername/cloudshaver/internal/pricing/client"
+)
+
+// staticVolumeUpgrades are the recommended upgrades written into generated
+// static pricing data
+var staticVolumeUpgrades = map[string]string{
+	"gp2": "gp3",
+	"io1": "io2",
+}
+
+// GenerateStaticPricing distills the EC2 offer files of regions into the
+// static pricing format: Linux on-demand prices of every instance type with
+// their recommended upgrade and downgrade, EBS volume and snapshot prices, and
+// region names from the offer metadata. Regions are decoded one at a time and
+// released, so memory stays that of a single offer index. The savings
+// opportunities are not in the offer files and are copied from previous,
+// which may be nil.
+func GenerateStaticPricing(ctx context.Context, source client.Source, regions []string, previous *EC2Pricing) (*EC2Pricing, error) {
+	pricing := &EC2Pricing{
+		LastUpdated:       time.Now().UTC().Format("2006-01-02"),
+		RegionMapping:     make(map[string]string),
+		OnDemandInstances: make(map[string]map[string]Instance),
+		EBSVolumes:        make(map[string]map[string]Volume),
+		EBSSnapshots:      make(map[string]Snapshot),
+	}
+	if previous != nil {
+		pricing.SavingsOpportunities = previous.SavingsOpportunities
+	}
+
+	for _, region := range regions {
+		logrus.WithField("region", region).Info("Distilling pricing data")
+
+		index, err := decodeRegionOffer(ctx, source, region)
+		if err != nil {
+			return nil, err
+		}
+		distillRegion(pricing, region, index)
+	}
+
+	if err := pricing.validate(); err != nil {
+		return nil, fmt.Errorf("generated pricing data is invalid: %w", err)
+	}
+	return pricing, nil
+}
+
+func decodeRegionOffer(ctx context.Context, source client.Source, region string) (*offerIndex, error) {
+	body, err := source.OpenServicePricing(ctx, EC2Service, region)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get %s pricing data: %w", EC2Service, err)
+	}
+	defer body.Close()
+
+	index, err := decodeOffer(ctx, body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse %s pricing data for %s: %w", EC2Service, region, err)
+	}
+	return index, nil
+}
+
+// distillRegion adds the prices of one region to pricing. Regions without
+// priced instances are left out entirely.
+func distillRegion(pricing *EC2Pricing, region string, index *offerIndex) {
+	catalog := newInstanceCatalog(region, index)
+
+	instances := make(map[string]Instance)
+	for _, instanceType := range catalog.Types() {
+		spec, _ := catalog.Lookup(instanceType)
+		if spec.PricePerHour <= 0 || spec.VCPU <= 0 || spec.MemoryGiB <= 0 {
+			continue
+		}
+		instance := Instance{
+			VCPU:                 spec.VCPU,
+			MemoryGiB:            spec.MemoryGiB,
+			PricePerHour:         spec.PricePerHour,
+			RecommendedDowngrade: smallerSize(catalog, spec),
+		}
+		for _, candidate := range catalog.UpgradeCandidates(instanceType) {
+			if candidate.Reason == UpgradeNewerGeneration {
+				instance.RecommendedUpgrade = candidate.Spec.InstanceType
+				break
+			}
+		}
+		instances[instanceType] = instance
+	}
+	if len(instances) == 0 {
+		return
+	}
+	pricing.OnDemandInstances[region] = instances
+
+	for _, product := range index.instances {
+		if location := product.attributes.Location; location != "" {
+			pricing.RegionMapping[region] = location
+			break
+		}
+	}
+	if pricing.RegionMapping[region] == "" {
+		pricing.RegionMapping[region] = region
+	}
+
+	volumes := make(map[string]Volume)
+	for volumeType, usageType := range volumeStorageUsage {
+		price, ok := index.ebsPrice(usageType, "GB-Mo")
+		if !ok || price <= 0 {
+			continue
+		}
+		volume := Volume{PricePerGBMonth: price}
+		if usageType, ok := volumeIOPSUsage[volumeType]; ok {
+			volume.PricePerIOPSMonth, _ = index.ebsPrice(usageType, "IOPS-Mo")
+			volume.IOPSTiers = index.iopsTiers(volumeType)
+		}
+		if usageType, ok := volumeThroughputUsage[volumeType]; ok {
+			volume.PricePerMiBpsMonth, _ = index.throughputPrice(usageType)
+		}
+		if volumeType == "gp3" {
+			volume.IOPSIncluded = GP3BaselineIOPS
+			volume.ThroughputIncluded = GP3BaselineThroughput
+		}
+		volumes[volumeType] = volume
+	}
+	for volumeType, upgrade := range staticVolumeUpgrades {
+		if volume, ok := volumes[volumeType]; ok {
+			if _, ok := volumes[upgrade]; ok {
+				volume.RecommendedUpgrade = upgrade
+				volumes[volumeType] = volume
+			}
+		}
+	}
+	if len(volumes) > 0 {
+		pricing.EBSVolumes[region] = volumes
+	}
+
+	if price, ok := index.ebsPrice(snapshotUsage, "GB-Mo"); ok && price > 0 {
+		snapshot := Snapshot{PricePerGBMonth: price}
+		snapshot.ArchivePricePerGBMonth, _ = index.ebsPrice(snapshotArchiveUsage, "GB-Mo")
+		pricing.EBSSnapshots[region] = snapshot
+	}
+}
+
+// smallerSize returns the largest cheaper type of the same family with fewer
+// vCPUs than spec, or an empty string
+func smallerSize(catalog *InstanceCatalog, spec InstanceSpec) string {
+	var best InstanceSpec
+	for _, instanceType := range catalog.Types() {
+		candidate, _ := catalog.Lookup(instanceType)
+		if candidate.Family != spec.Family ||
+			candidate.VCPU >= spec.VCPU ||
+			candidate.PricePerHour <= 0 ||
+			candidate.PricePerHour >= spec.PricePerHour {
+			continue
+		}
+		if candidate.VCPU > best.VCPU || candidate.VCPU == best.VCPU && candidate.MemoryGiB > best.MemoryGiB {
+			best = candidate
+		}
+	}
+	return best.InstanceType
+}
+
+// PriceChange is a price that differs between two static pricing snapshots.
+// Old is zero for added prices and New is zero for removed ones.
+type PriceChange struct {
+	Region string
+	// Resource is "instance", "volume" or "snapshot"
+	Resource string
+	// Name is the instance or volume type
+	Name string
+	// Field is the JSON name of the price, e.g. "price_per_hour"
+	Field string
+	Old   float64
+	New   float64
+}
+
+// DiffPricing lists the prices that differ between two static pricing
+// snapshots, sorted by region, resource and name
+func DiffPricing(old, new *EC2Pricing) []PriceChange {
+	var changes []PriceChange
+	add := func(region, resource, name, field string, oldPrice, newPrice float64) {
+		if oldPrice != newPrice {
+			changes = append(changes, PriceChange{
+				Region: region, Resource: resource, Name: name, Field: field, Old: oldPrice, New: newPrice,
+			})
+		}
+	}
+
+	for _, region := range unionKeys(old.OnDemandInstances, new.OnDemandInstances) {
+		oldInstances, newInstances := old.OnDemandInstances[region], new.OnDemandInstances[region]
+		for _, instanceType := range unionKeys(oldInstances, newInstances) {
+			add(region, "instance", instanceType, "price_per_hour",
+				oldInstances[instanceType].PricePerHour, newInstances[instanceType].PricePerHour)
+		}
+	}
+
+	for _, region := range unionKeys(old.EBSVolumes, new.EBSVolumes) {
+		oldVolumes, newVolumes := old.EBSVolumes[region], new.EBSVolumes[region]
+		for _, volumeType := range unionKeys(oldVolumes, newVolumes) {
+			oldVolume, newVolume := oldVolumes[volumeType], newVolumes[volumeType]
+			add(region, "volume", volumeType, "price_per_gb_month", oldVolume.PricePerGBMonth, newVolume.PricePerGBMonth)
+			add(region, "volume", volumeType, "price_per_iops_month", oldVolume.PricePerIOPSMonth, newVolume.PricePerIOPSMonth)
+			add(region, "volume", volumeType, "price_per_mibps_month", oldVolume.PricePerMiBpsMonth, newVolume.PricePerMiBpsMonth)
+			oldTiers, newTiers := tierPrices(oldVolume.IOPSTiers), tierPrices(newVolume.IOPSTiers)
+			for _, from := range unionKeys(oldTiers, newTiers) {
+				add(region, "volume", volumeType, "iops_tiers."+from+".price_per_iops_month", oldTiers[from], newTiers[from])
+			}
+		}
+	}
+
+	for _, region := range unionKeys(old.EBSSnapshots, new.EBSSnapshots) {
+		oldSnapshot, newSnapshot := old.EBSSnapshots[region], new.EBSSnapshots[region]
+		add(region, "snapshot", "standard", "price_per_gb_month", oldSnapshot.PricePerGBMonth, newSnapshot.PricePerGBMonth)
+		add(region, "snapshot", "archive", "archive_price_per_gb_month", oldSnapshot.ArchivePricePerGBMonth, newSnapshot.ArchivePricePerGBMonth)
+	}
+
+	return changes
+}
+
+// tierPrices maps the start of each IOPS tier to its price
+func tierPrices(tiers []IOPSTier) map[string]float64 {
+	prices := make(map[string]float64, len(tiers))
+	for _, tier := range tiers {
+		prices[strconv.Itoa(tier.FromIOPS)] = tier.PricePerIOPSMonth
+	}
+	return prices
+}
+
+// unionKeys returns the keys present in either map, sorted
+func unionKeys[V any](a, b map[string]V) []string {
+	keys := sortedKeys(a)
+	for key := range b {
+		if _, ok := a[key]; !ok {
+			keys = append(keys, key)
+		}
+	}
+	sort.Strings(keys)
+	return keys
+}
@@ -0,0 +1,56 @@
+package aws
+
+import (
+	"context"
+	"sync"
+)
+
+// keyedLoader caches values that are expensive to load, such as decoded
+// offer files, by key. Concurrent callers of one key wait for a single load
+// while other keys load in parallel. Failed loads are not cached, so a later
+// call retries.
+type keyedLoader[V any] struct {
+	mu      sync.Mutex
+	entries map[string]*loadEntry[V]
+}
+
+// loadEntry is a value of a keyedLoader; done is closed once it is loaded
+type loadEntry[V any] struct {
+	done  chan struct{}
+	value V
+	err   error
+}
+
+// load returns the value of key, calling fn to load it unless it is loaded
+// or being loaded
+func (l *keyedLoader[V]) load(ctx context.Context, key string, fn func() (V, error)) (V, error) {
+	l.mu.Lock()
+	entry, ok := l.entries[key]
+	if !ok {
+		if l.entries == nil {
+			l.entries = make(map[string]*loadEntry[V])
+		}
+		entry = &loadEntry[V]{done: make(chan struct{})}
+		l.entries[key] = entry
+	}
+	l.mu.Unlock()
+
+	if ok {
+		select {
+		case <-entry.done:
+			return entry.value, entry.err
+		case <-ctx.Done():
+			var zero V
+			return zero, ctx.Err()
+		}
+	}
+
+	entry.value, entry.err = fn()
+	if entry.err != nil {
+		l.mu.Lock()
+		delete(l.entries, key)
+		l.mu.Unlock()
+	}
+	close(entry.done)
+	return entry.value, entry.err
+}
@@ -0,0 +1,333 @@
+package aws
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"reflect"
+	"strings"
+)
+
+// offerPrice is one on-demand price dimension of a SKU, in USD
+type offerPrice struct {
+	unit  string
+	price float64
+}
+
+// offerProduct is the part of an offer file product kept in the index
+type offerProduct struct {
+	sku           string
+	productFamily string
+	attributes    ProductAttributes
+	onDemand      []offerPrice
+	reserved      []CommitmentPrice
+}
+
+// instanceKey identifies the SKU of an instance type on one platform. The
+// operation implies the operating system and pre-installed software.
+type instanceKey struct {
+	instanceType   string
+	tenancy        string
+	capacityStatus string
+	licenseModel   string
+	operation      string
+}
+
+func productInstanceKey(attrs ProductAttributes) instanceKey {
+	return instanceKey{
+		instanceType:   attrs.InstanceType,
+		tenancy:        attrs.Tenancy,
+		capacityStatus: attrs.CapacityStatus,
+		licenseModel:   attrs.LicenseModel,
+		operation:      attrs.Operation,
+	}
+}
+
+// platformInstanceKey is the key of the on-demand SKU of an instance type
+// running platform on shared hardware
+func platformInstanceKey(instanceType string, platform Platform) instanceKey {
+	return instanceKey{
+		instanceType:   instanceType,
+		tenancy:        sharedTenancy,
+		capacityStatus: capacityUsed,
+		licenseModel:   platform.LicenseModel,
+		operation:      platform.Operation,
+	}
+}
+
+// offerIndex is a compact, queryable form of a regional offer file. It keeps
+// one offerProduct per SKU with its on-demand prices parsed to float64, the
+// reserved terms of compute instances, and
+// interns every attribute string so the handful of distinct values repeated
+// across SKUs are stored once. Price descriptions and effective dates are
+// dropped while decoding.
+//
+// The memory budget is therefore about 400 bytes per SKU, plus about 100
+// bytes per reserved offering of a compute SKU, plus the distinct strings: a
+// regional AmazonEC2 offer file of a few hundred MB and some 200,000 SKUs
+// indexes into roughly 150 MB, and the decoder itself only holds one product
+// or term at a time.
+type offerIndex struct {
+	version         string
+	publicationDate string
+	products        map[string]*offerProduct
+	instances       map[instanceKey]*offerProduct
+	// ebsUsage holds the EBS storage, IOPS, throughput and snapshot SKUs
+	// keyed by usage type without its region prefix, e.g.
+	// "EBS:VolumeP-IOPS.gp3"
+	ebsUsage map[string]*offerProduct
+}
+
+// instance returns the compute instance product with exactly the attributes of key
+func (x *offerIndex) instance(key instanceKey) (*offerProduct, bool) {
+	product, ok := x.instances[key]
+	return product, ok
+}
+
+// ebsPrice returns the on-demand price of the EBS SKU of a usage type in unit
+func (x *offerIndex) ebsPrice(usageType, unit string) (float64, bool) {
+	product, ok := x.ebsUsage[usageType]
+	if !ok {
+		return 0, false
+	}
+	for _, price := range product.onDemand {
+		if strings.EqualFold(price.unit, unit) {
+			return price.price, true
+		}
+	}
+	return 0, false
+}
+
+// offerIndex returns the index of a service offer file in a region, streaming
+// and decoding the file on first use. Offer files of other services and
+// regions are decoded concurrently.
+func (s *EC2PricingService) offerIndex(ctx context.Context, service, region string) (*offerIndex, error) {
+	return s.offers.load(ctx, service+"/"+region, func() (*offerIndex, error) {
+		body, err := s.client.OpenServicePricing(ctx, service, region)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get %s pricing data: %w", service, err)
+		}
+		defer body.Close()
+
+		index, err := decodeOffer(ctx, body)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse %s pricing data for %s: %w", service, region, err)
+		}
+		return index, nil
+	})
+}
+
+// decodeOffer streams an offer file into an index without holding the whole
+// document in memory
+func decodeOffer(ctx context.Context, r io.Reader) (*offerIndex, error) {
+	dec := json.NewDecoder(bufio.NewReaderSize(r, 1<<20))
+	index := &offerIndex{
+		products:  make(map[string]*offerProduct),
+		instances: make(map[instanceKey]*offerProduct),
+		ebsUsage:  make(map[string]*offerProduct),
+	}
+	strs := make(map[string]string)
+	onDemand := make(map[string][]offerPrice)
+	reserved := make(map[string][]CommitmentPrice)
+
+	err := decodeObject(dec, func(key string) error {
+		switch key {
+		case "version":
+			return dec.Decode(&index.version)
+		case "publicationDate":
+			return dec.Decode(&index.publicationDate)
+		case "products":
+			return decodeObject(dec, func(sku string) error {
+				if err := ctx.Err(); err != nil {
+					return err
+				}
+				var product struct {
+					ProductFamily string            `json:"productFamily"`
+					Attributes    ProductAttributes `json:"attributes"`
+				}
+				if err := dec.Decode(&product); err != nil {
+					return fmt.Errorf("product %s: %w", sku, err)
+				}
+				internStrings(strs, &product.Attributes)
+				index.products[sku] = &offerProduct{
+					sku:           sku,
+					productFamily: intern(strs, product.ProductFamily),
+					attributes:    product.Attributes,
+				}
+				return nil
+			})
+		case "terms":
+			return decodeObject(dec, func(termType string) error {
+				switch termType {
+				case "OnDemand":
+					return decodeObject(dec, func(sku string) error {
+						if err := ctx.Err(); err != nil {
+							return err
+						}
+						prices, err := decodeOnDemandTerms(dec, strs)
+						if err != nil {
+							return fmt.Errorf("terms of %s: %w", sku, err)
+						}
+						onDemand[sku] = prices
+						return nil
+					})
+				case "Reserved":
+					return decodeObject(dec, func(sku string) error {
+						if err := ctx.Err(); err != nil {
+							return err
+						}
+						// Products usually precede terms, which lets the
+						// reserved terms of anything but instances be skipped
+						if product, ok := index.products[sku]; ok && !isComputeInstance(product) {
+							return skipValue(dec)
+						}
+						prices, err := decodeReservedTerms(dec, strs)
+						if err != nil {
+							return fmt.Errorf("reserved terms of %s: %w", sku, err)
+						}
+						reserved[sku] = prices
+						return nil
+					})
+				default:
+					return skipValue(dec)
+				}
+			})
+		default:
+			return skipValue(dec)
+		}
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	// Terms may precede products in the document, so they are joined last
+	for sku, product := range index.products {
+		product.onDemand = onDemand[sku]
+		if isComputeInstance(product) {
+			product.reserved = reserved[sku]
+			key := productInstanceKey(product.attributes)
+			// Keep the lowest SKU should two products share a key, so the
+			// price does not depend on map iteration order
+			if existing, ok := index.instances[key]; !ok || sku < existing.sku {
+				index.instances[key] = product
+			}
+		}
+		// Usage types carry a region prefix outside us-east-1, e.g.
+		// "EUW1-EBS:VolumeUsage.gp2"
+		if i := strings.Index(product.attributes.UsageType, "EBS:"); i >= 0 {
+			key := product.attributes.UsageType[i:]
+			if existing, ok := index.ebsUsage[key]; !ok || sku < existing.sku {
+				index.ebsUsage[key] = product
+			}
+		}
+	}
+
+	return index, nil
+}
+
+// isComputeInstance reports whether a product is an instance type, including
+// bare metal types
+func isComputeInstance(product *offerProduct) bool {
+	return product.attributes.InstanceType != "" && strings.HasPrefix(product.productFamily, "Compute Instance")
+}
+
+// decodeOnDemandTerms decodes the on-demand offers of one SKU into its USD prices
+func decodeOnDemandTerms(dec *json.Decoder, strs map[string]string) ([]offerPrice, error) {
+	var terms map[string]struct {
+		PriceDimensions map[string]PriceDimension `json:"priceDimensions"`
+	}
+	if err := dec.Decode(&terms); err != nil {
+		return nil, err
+	}
+
+	var prices []offerPrice
+	for _, term := range terms {
+		for _, dimension := range term.PriceDimensions {
+			priceStr, ok := dimension.PricePerUnit["USD"]
+			if !ok {
+				continue
+			}
+			price, err := parsePrice(priceStr)
+			if err != nil {
+				return nil, err
+			}
+			prices = append(prices, offerPrice{unit: intern(strs, dimension.Unit), price: price})
+		}
+	}
+	return prices, nil
+}
+
+// decodeObject reads a JSON object, calling fn with the decoder positioned at
+// the value of each key. fn must consume exactly that value.
+func decodeObject(dec *json.Decoder, fn func(key string) error) error {
+	if err := expectDelim(dec, '{'); err != nil {
+		return err
+	}
+	for dec.More() {
+		token, err := dec.Token()
+		if err != nil {
+			return err
+		}
+		key, ok := token.(string)
+		if !ok {
+			return fmt.Errorf("expected object key, got %v", token)
+		}
+		if err := fn(key); err != nil {
+			return err
+		}
+	}
+	return expectDelim(dec, '}')
+}
+
+func expectDelim(dec *json.Decoder, delim json.Delim) error {
+	token, err := dec.Token()
+	if err != nil {
+		return err
+	}
+	if token != delim {
+		return fmt.Errorf("expected %v, got %v", delim, token)
+	}
+	return nil
+}
+
+// skipValue consumes the next value token by token, so large sections such
+// as reserved terms are never materialised
+func skipValue(dec *json.Decoder) error {
+	depth := 0
+	for {
+		token, err := dec.Token()
+		if err != nil {
+			return err
+		}
+		switch token {
+		case json.Delim('{'), json.Delim('['):
+			depth++
+		case json.Delim('}'), json.Delim(']'):
+			depth--
+		}
+		if depth == 0 {
+			return nil
+		}
+	}
+}
+
+func intern(strs map[string]string, s string) string {
+	if interned, ok := strs[s]; ok {
+		return interned
+	}
+	strs[s] = s
+	return s
+}
+
+// internStrings interns every string field of attrs, so fields added to
+// ProductAttributes are covered without listing them here
+func internStrings(strs map[string]string, attrs *ProductAttributes) {
+	value := reflect.ValueOf(attrs).Elem()
+	for i := 0; i < value.NumField(); i++ {
+		if field := value.Field(i); field.Kind() == reflect.String {
+			field.SetString(intern(strs, field.String()))
+		}
+	}
+}
@@ -0,0 +1,256 @@
+package aws
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/yourusername/cloudshaver/internal/pricing/client"
+)
+
+// testRegion is the region of the offer files in testdata/snapshot
+const testRegion = "eu-west-1"
+
+// testSnapshot is a pricing snapshot holding small offer files
+var testSnapshot = filepath.Join("testdata", "snapshot")
+
+// countingSource counts the offer files opened from a source and fails the
+// first failures opens
+type countingSource struct {
+	client.Source
+	mu       sync.Mutex
+	opens    int
+	failures int
+}
+
+func (s *countingSource) OpenServicePricing(ctx context.Context, service, region string) (io.ReadCloser, error) {
+	s.mu.Lock()
+	s.opens++
+	fail := s.opens <= s.failures
+	s.mu.Unlock()
+
+	if fail {
+		return nil, errors.New("unavailable")
+	}
+	return s.Source.OpenServicePricing(ctx, service, region)
+}
+
+func newTestSource(t testing.TB) *countingSource {
+	t.Helper()
+	snapshot, err := client.NewSnapshotSource(testSnapshot)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return &countingSource{Source: snapshot}
+}
+
+// newTestPricingService returns a pricing service reading the offer files
+// in testdata/snapshot
+func newTestPricingService(t testing.TB) *EC2PricingService {
+	t.Helper()
+	service, err := NewEC2PricingService(context.Background(), newTestSource(t))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return service
+}
+
+func readTestOffer(t testing.TB) []byte {
+	t.Helper()
+	data, err := os.ReadFile(filepath.Join(testSnapshot, EC2Service, testRegion+".json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return data
+}
+
+func TestDecodeOffer(t *testing.T) {
+	index, err := decodeOffer(context.Background(), bytes.NewReader(readTestOffer(t)))
+	if err != nil {
+		t.Fatalf("decodeOffer: %v", err)
+	}
+
+	if index.version != "20240101000000" || index.publicationDate != "2024-01-01T00:00:00Z" {
+		t.Errorf("version = %s, publication date = %s", index.version, index.publicationDate)
+	}
+	if len(index.products) != 27 {
+		t.Errorf("products = %d, want 27", len(index.products))
+	}
+
+	// Dedicated Hosts carry an instance type but are not instances
+	if len(index.instances) != 10 {
+		t.Errorf("instances = %d, want 10", len(index.instances))
+	}
+	for key, product := range index.instances {
+		if product.productFamily == "Dedicated Host" {
+			t.Errorf("instance %v is a Dedicated Host", key)
+		}
+	}
+
+	linux, ok := index.instance(platformInstanceKey("t3.micro", PlatformLinux))
+	if !ok {
+		t.Fatal("no t3.micro Linux instance")
+	}
+	if len(linux.onDemand) != 1 || linux.onDemand[0].price != 0.0114 || linux.onDemand[0].unit != "Hrs" {
+		t.Errorf("t3.micro on-demand = %+v", linux.onDemand)
+	}
+	if len(linux.reserved) != 2 {
+		t.Errorf("t3.micro reserved offerings = %d, want 2", len(linux.reserved))
+	}
+
+	// Usage types are keyed without their region prefix
+	if price, ok := index.ebsPrice("EBS:VolumeUsage.gp3", "GB-Mo"); !ok || price != 0.088 {
+		t.Errorf("gp3 storage = %v, %v", price, ok)
+	}
+	if _, ok := index.ebsPrice("EBS:VolumeUsage.gp3", "IOPS-Mo"); ok {
+		t.Error("gp3 storage has an IOPS-Mo price")
+	}
+	if product := index.ebsUsage["EBS:VolumeUsage.gp2"]; product == nil || product.reserved != nil {
+		t.Errorf("gp2 storage = %+v", product)
+	}
+}
+
+func TestDecodeOfferTermsBeforeProducts(t *testing.T) {
+	offer := `{
+		"terms": {"OnDemand": {"SKU1": {"SKU1.T": {"priceDimensions": {"SKU1.T.D": {"unit": "Hrs", "pricePerUnit": {"USD": "0.5"}}}}}}},
+		"products": {"SKU1": {"productFamily": "Compute Instance", "attributes": {
+			"instanceType": "c5.large", "tenancy": "Shared", "capacitystatus": "Used",
+			"licenseModel": "No License required", "operation": "RunInstances"}}}
+	}`
+
+	index, err := decodeOffer(context.Background(), strings.NewReader(offer))
+	if err != nil {
+		t.Fatalf("decodeOffer: %v", err)
+	}
+	product, ok := index.instance(platformInstanceKey("c5.large", PlatformLinux))
+	if !ok || len(product.onDemand) != 1 || product.onDemand[0].price != 0.5 {
+		t.Errorf("c5.large = %+v, %v", product, ok)
+	}
+}
+
+func TestDecodeOfferCanceled(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	if _, err := decodeOffer(ctx, bytes.NewReader(readTestOffer(t))); !errors.Is(err, context.Canceled) {
+		t.Errorf("decodeOffer error = %v, want %v", err, context.Canceled)
+	}
+}
+
+func TestOfferIndexLoadsOnce(t *testing.T) {
+	source := newTestSource(t)
+	service, err := NewEC2PricingService(context.Background(), source)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var wg sync.WaitGroup
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if _, err := service.GetInstancePrice(context.Background(), "t3.micro", testRegion, PlatformLinux); err != nil {
+				t.Error(err)
+			}
+		}()
+	}
+	wg.Wait()
+
+	if source.opens != 1 {
+		t.Errorf("offer file opened %d times, want 1", source.opens)
+	}
+}
+
+func TestOfferIndexRetriesFailedLoad(t *testing.T) {
+	source := newTestSource(t)
+	source.failures = 1
+	service, err := NewEC2PricingService(context.Background(), source)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := service.GetVolumePrice(context.Background(), "gp2", testRegion); err == nil {
+		t.Error("first load succeeded despite the failing source")
+	}
+	if price, err := service.GetVolumePrice(context.Background(), "gp2", testRegion); err != nil || price != 0.11 {
+		t.Errorf("GetVolumePrice after a failed load = %v, %v", price, err)
+	}
+}
+
+func BenchmarkDecodeOffer(b *testing.B) {
+	data := readTestOffer(b)
+	ctx := context.Background()
+
+	b.SetBytes(int64(len(data)))
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		if _, err := decodeOffer(ctx, bytes.NewReader(data)); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+// benchmarkLookups are the instances priced per iteration of
+// BenchmarkInstancePriceLookups, cycling through the types of the fixture
+const benchmarkLookups = 200
+
+// BenchmarkInstancePriceLookups prices benchmarkLookups instances against
+// one decoded offer index, as a scan does, and against an offer file decoded
+// for every lookup, as before the index was kept
+func BenchmarkInstancePriceLookups(b *testing.B) {
+	lookups := []struct {
+		instanceType string
+		platform     Platform
+	}{
+		{"t3.micro", PlatformLinux},
+		{"t3.micro", InstancePlatform("Windows", OperationWindows)},
+		{"t3.micro", InstancePlatform("Red Hat Enterprise Linux", OperationRHEL)},
+		{"m5.large", PlatformLinux},
+		{"m5.large", InstancePlatform("Windows with SQL Server Standard", OperationWindowsSQLStd)},
+		{"m5.metal", PlatformLinux},
+	}
+	ctx := context.Background()
+
+	b.Run("index", func(b *testing.B) {
+		service := newTestPricingService(b)
+		if _, err := service.GetInstancePrice(ctx, "t3.micro", testRegion, PlatformLinux); err != nil {
+			b.Fatal(err)
+		}
+
+		b.ReportAllocs()
+		b.ResetTimer()
+		for i := 0; i < b.N; i++ {
+			for j := 0; j < benchmarkLookups; j++ {
+				lookup := lookups[j%len(lookups)]
+				if _, err := service.GetInstancePrice(ctx, lookup.instanceType, testRegion, lookup.platform); err != nil {
+					b.Fatal(err)
+				}
+			}
+		}
+	})
+
+	b.Run("decode-per-lookup", func(b *testing.B) {
+		data := readTestOffer(b)
+
+		b.ReportAllocs()
+		b.ResetTimer()
+		for i := 0; i < b.N; i++ {
+			for j := 0; j < benchmarkLookups; j++ {
+				lookup := lookups[j%len(lookups)]
+				index, err := decodeOffer(ctx, bytes.NewReader(data))
+				if err != nil {
+					b.Fatal(err)
+				}
+				if product, ok := index.instance(platformInstanceKey(lookup.instanceType, lookup.platform)); !ok || len(product.onDemand) == 0 {
+					b.Fatalf("no price for %s", lookup.instanceType)
+				}
+			}
+		}
+	})
+}
@@ -0,0 +1,72 @@
+package aws
+
+import "strings"
+
+// Billing operations of common platforms. An instance reports its operation
+// as UsageOperation, and the offer file lists it as the "operation" attribute
+// of each compute SKU, so the two can be matched directly.
+const (
+	OperationLinux         = "RunInstances"
+	OperationWindows       = "RunInstances:0002"
+	OperationWindowsBYOL   = "RunInstances:0800"
+	OperationRHEL          = "RunInstances:0010"
+	OperationSUSE          = "RunInstances:000g"
+	OperationWindowsSQLStd = "RunInstances:0006"
+	OperationWindowsSQLEnt = "RunInstances:0102"
+	OperationWindowsSQLWeb = "RunInstances:0202"
+	OperationLinuxSQLStd   = "RunInstances:0004"
+	OperationLinuxSQLEnt   = "RunInstances:0100"
+	OperationLinuxSQLWeb   = "RunInstances:0200"
+)
+
+// License models of compute SKUs
+const (
+	LicenseIncluded = "No License required"
+	LicenseBYOL     = "Bring your own license"
+)
+
+// Platform describes the operating system and license an instance is billed
+// for, so it can be priced like-for-like with its alternatives
+type Platform struct {
+	// Name is the platform as DescribeInstances reports it in
+	// PlatformDetails, e.g. "Windows BYOL"
+	Name string
+	// Operation is the billing operation, e.g. "RunInstances:0002"
+	Operation string
+	// LicenseModel is LicenseIncluded or LicenseBYOL
+	LicenseModel string
+}
+
+// PlatformLinux is the platform of Linux/UNIX instances without licensed software
+var PlatformLinux = Platform{
+	Name:         "Linux/UNIX",
+	Operation:    OperationLinux,
+	LicenseModel: LicenseIncluded,
+}
+
+// InstancePlatform derives the platform of an instance from the
+// PlatformDetails and UsageOperation that DescribeInstances returns. An
+// instance without a usage operation is assumed to run Linux.
+func InstancePlatform(platformDetails, usageOperation string) Platform {
+	if usageOperation == "" {
+		return PlatformLinux
+	}
+
+	platform := Platform{
+		Name:         platformDetails,
+		Operation:    usageOperation,
+		LicenseModel: LicenseIncluded,
+	}
+	if platform.Name == "" {
+		platform.Name = usageOperation
+	}
+	if usageOperation == OperationWindowsBYOL || strings.Contains(strings.ToUpper(platformDetails), "BYOL") {
+		platform.LicenseModel = LicenseBYOL
+	}
+	return platform
+}
+
+// IsLinux reports whether the platform is plain Linux/UNIX
+func (p Platform) IsLinux() bool {
+	return p.Operation == OperationLinux
+}
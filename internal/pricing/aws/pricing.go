@@ -1,10 +1,17 @@
 package aws
 
 import (
+    "bytes"
+    "context"
+    _ "embed"
     "encoding/json"
+    "errors"
     "fmt"
     "os"
     "path/filepath"
+    "sort"
+    "strconv"
+    "sync"
     "time"
 )
 
@@ -13,12 +20,13 @@ type EC2Pricing struct {
     RegionMapping       map[string]string                `json:"region_mapping"`
     OnDemandInstances   map[string]map[string]Instance   `json:"on_demand_instances"`
     EBSVolumes         map[string]map[string]Volume     `json:"ebs_volumes"`
+    EBSSnapshots       map[string]Snapshot              `json:"ebs_snapshots"`
     SavingsOpportunities SavingsOpportunities            `json:"savings_opportunities"`
 }
 
 type Instance struct {
     VCPU               int     `json:"vcpu"`
-    MemoryGiB         int     `json:"memory_gib"`
+    MemoryGiB         float64 `json:"memory_gib"`
     PricePerHour      float64 `json:"price_per_hour"`
     RecommendedUpgrade string  `json:"recommended_upgrade,omitempty"`
     RecommendedDowngrade string `json:"recommended_downgrade,omitempty"`
@@ -30,9 +38,23 @@ type Volume struct {
     IOPSIncluded        int     `json:"iops_included,omitempty"`
     ThroughputIncluded  int     `json:"throughput_included_mibps,omitempty"`
     PricePerIOPSMonth   float64 `json:"price_per_iops_month,omitempty"`
+    PricePerMiBpsMonth  float64 `json:"price_per_mibps_month,omitempty"`
+    IOPSTiers           []IOPSTier `json:"iops_tiers,omitempty"`
     RecommendedUpgrade  string  `json:"recommended_upgrade,omitempty"`
 }
 
+// IOPSTier is a lower IOPS price that applies to the IOPS provisioned above
+// FromIOPS, as for io2
+type IOPSTier struct {
+    FromIOPS          int     `json:"from_iops"`
+    PricePerIOPSMonth float64 `json:"price_per_iops_month"`
+}
+
+type Snapshot struct {
+    PricePerGBMonth        float64 `json:"price_per_gb_month"`
+    ArchivePricePerGBMonth float64 `json:"archive_price_per_gb_month,omitempty"`
+}
+
 type SavingsOpportunities struct {
     InstanceUpgrade struct {
         T2ToT3 struct {
@@ -45,6 +67,10 @@ type SavingsOpportunities struct {
             MinimumDays           int     `json:"minimum_days"`
             Description           string  `json:"description"`
         } `json:"oversized"`
+        Stopped struct {
+            MinimumDays int    `json:"minimum_days"`
+            Description string `json:"description"`
+        } `json:"stopped"`
     } `json:"instance_upgrade"`
     VolumeOptimization struct {
         GP2ToGP3 struct {
@@ -57,122 +83,313 @@ type SavingsOpportunities struct {
             Description string `json:"description"`
         } `json:"unattached"`
         Underutilized struct {
-            IOPSThreshold   int    `json:"iops_threshold"`
-            SizeThresholdGB int    `json:"size_threshold_gb"`
+            HeadroomPercent int    `json:"headroom_percent"`
+            MinimumDays     int    `json:"minimum_days"`
             Description     string `json:"description"`
+            // Deprecated: IOPSThreshold and SizeThresholdGB are accepted so
+            // older override files still load, and ignored; volumes are
+            // right-sized from their metrics instead.
+            IOPSThreshold   int    `json:"iops_threshold,omitempty"`
+            SizeThresholdGB int    `json:"size_threshold_gb,omitempty"`
         } `json:"underutilized"`
     } `json:"volume_optimization"`
 }
 
-var pricingData *EC2Pricing
+// PricingFile is the name of the static pricing data file, bundled into the
+// binary and looked up in override directories
+const PricingFile = "ec2_pricing.json"
+
+//go:embed data/ec2_pricing.json
+var bundledPricing []byte
+
+// staticPricing caches the static pricing data for every caller of
+// LoadPricing
+var staticPricing struct {
+    sync.Mutex
+    path string
+    data *EC2Pricing
+}
+
+// SetPricingPath makes LoadPricing read the static pricing data from path
+// instead of the bundled copy. path is either a JSON file or a directory
+// holding ec2_pricing.json; an empty path restores the bundled data.
+func SetPricingPath(path string) {
+    staticPricing.Lock()
+    defer staticPricing.Unlock()
+
+    staticPricing.path = path
+    staticPricing.data = nil
+}
 
-// LoadPricing loads the pricing data from the JSON file
+// LoadPricing loads and validates the static pricing data, from the bundled
+// copy or the file set with SetPricingPath. It is read once and shared, so
+// callers must not modify the result.
 func LoadPricing() (*EC2Pricing, error) {
-    if pricingData != nil {
-        return pricingData, nil
+    staticPricing.Lock()
+    defer staticPricing.Unlock()
+
+    if staticPricing.data != nil {
+        return staticPricing.data, nil
     }
 
-    // Get the directory of the current file
-    dir, err := os.Getwd()
-    if err != nil {
-        return nil, fmt.Errorf("failed to get current directory: %v", err)
+    data, name := bundledPricing, "bundled "+PricingFile
+    if path := staticPricing.path; path != "" {
+        if info, err := os.Stat(path); err == nil && info.IsDir() {
+            path = filepath.Join(path, PricingFile)
+        }
+        var err error
+        if data, err = os.ReadFile(path); err != nil {
+            return nil, fmt.Errorf("failed to read pricing data: %v", err)
+        }
+        name = path
     }
 
-    // Construct path to the pricing data file
-    pricingFile := filepath.Join(dir, "internal", "pricing", "aws", "data", "ec2_pricing.json")
-    data, err := os.ReadFile(pricingFile)
+    pricing, err := ParsePricing(data)
     if err != nil {
-        return nil, fmt.Errorf("failed to read pricing data: %v", err)
+        return nil, fmt.Errorf("invalid pricing data in %s: %w", name, err)
     }
 
+    staticPricing.data = pricing
+    return pricing, nil
+}
+
+// ParsePricing decodes static pricing data and validates it against the
+// schema: unknown fields are rejected, prices must be positive and every
+// recommended upgrade or downgrade must exist in the same region
+func ParsePricing(data []byte) (*EC2Pricing, error) {
+    dec := json.NewDecoder(bytes.NewReader(data))
+    dec.DisallowUnknownFields()
+
     pricing := &EC2Pricing{}
-    if err := json.Unmarshal(data, pricing); err != nil {
+    if err := dec.Decode(pricing); err != nil {
         return nil, fmt.Errorf("failed to parse pricing data: %v", err)
     }
+    if err := pricing.validate(); err != nil {
+        return nil, err
+    }
+    return pricing, nil
+}
+
+// validate reports every schema violation of the pricing data at once
+func (p *EC2Pricing) validate() error {
+    var errs []error
+    addErr := func(format string, args ...interface{}) {
+        errs = append(errs, fmt.Errorf(format, args...))
+    }
 
     // Validate last updated date
-    lastUpdated, err := time.Parse("2006-01-02", pricing.LastUpdated)
-    if err != nil {
-        return nil, fmt.Errorf("invalid last_updated date format: %v", err)
+    if _, err := time.Parse("2006-01-02", p.LastUpdated); err != nil {
+        addErr("invalid last_updated date format: %v", err)
     }
 
-    // Warn if pricing data is older than 30 days
-    if time.Since(lastUpdated) > 30*24*time.Hour {
-        fmt.Printf("Warning: Pricing data is more than 30 days old (last updated: %s)\n", pricing.LastUpdated)
+    checkRegion := func(section, region string) {
+        if _, ok := p.RegionMapping[region]; !ok {
+            addErr("%s: region %s is missing from region_mapping", section, region)
+        }
     }
 
-    pricingData = pricing
-    return pricing, nil
+    for _, region := range sortedKeys(p.OnDemandInstances) {
+        checkRegion("on_demand_instances", region)
+        instances := p.OnDemandInstances[region]
+        for _, instanceType := range sortedKeys(instances) {
+            instance := instances[instanceType]
+            where := fmt.Sprintf("on_demand_instances.%s.%s", region, instanceType)
+            if instance.PricePerHour <= 0 {
+                addErr("%s: price_per_hour must be positive", where)
+            }
+            if instance.VCPU <= 0 || instance.MemoryGiB <= 0 {
+                addErr("%s: vcpu and memory_gib must be positive", where)
+            }
+            if _, ok := instances[instance.RecommendedUpgrade]; instance.RecommendedUpgrade != "" && !ok {
+                addErr("%s: recommended_upgrade %s is not priced in %s", where, instance.RecommendedUpgrade, region)
+            }
+            if _, ok := instances[instance.RecommendedDowngrade]; instance.RecommendedDowngrade != "" && !ok {
+                addErr("%s: recommended_downgrade %s is not priced in %s", where, instance.RecommendedDowngrade, region)
+            }
+        }
+    }
+
+    for _, region := range sortedKeys(p.EBSVolumes) {
+        checkRegion("ebs_volumes", region)
+        volumes := p.EBSVolumes[region]
+        for _, volumeType := range sortedKeys(volumes) {
+            volume := volumes[volumeType]
+            where := fmt.Sprintf("ebs_volumes.%s.%s", region, volumeType)
+            if volume.PricePerGBMonth <= 0 {
+                addErr("%s: price_per_gb_month must be positive", where)
+            }
+            if volume.BasePricePerMonth < 0 || volume.PricePerIOPSMonth < 0 || volume.PricePerMiBpsMonth < 0 ||
+                volume.IOPSIncluded < 0 || volume.ThroughputIncluded < 0 {
+                addErr("%s: prices and included amounts must not be negative", where)
+            }
+            for i, tier := range volume.IOPSTiers {
+                if tier.FromIOPS <= 0 || tier.PricePerIOPSMonth < 0 || i > 0 && tier.FromIOPS <= volume.IOPSTiers[i-1].FromIOPS {
+                    addErr("%s: iops_tiers must have ascending, positive from_iops and non-negative prices", where)
+                    break
+                }
+            }
+            if _, ok := volumes[volume.RecommendedUpgrade]; volume.RecommendedUpgrade != "" && !ok {
+                addErr("%s: recommended_upgrade %s is not priced in %s", where, volume.RecommendedUpgrade, region)
+            }
+        }
+    }
+
+    for _, region := range sortedKeys(p.EBSSnapshots) {
+        checkRegion("ebs_snapshots", region)
+        if p.EBSSnapshots[region].PricePerGBMonth <= 0 {
+            addErr("ebs_snapshots.%s: price_per_gb_month must be positive", region)
+        }
+        if p.EBSSnapshots[region].ArchivePricePerGBMonth < 0 {
+            addErr("ebs_snapshots.%s: archive_price_per_gb_month must not be negative", region)
+        }
+    }
+
+    return errors.Join(errs...)
 }
 
-// CalculateInstanceSavings calculates potential savings for an EC2 instance
-func (p *EC2Pricing) CalculateInstanceSavings(region, instanceType string, hoursRunning int) (float64, string, error) {
-    regionPricing, ok := p.OnDemandInstances[region]
-    if !ok {
-        return 0, "", fmt.Errorf("pricing not available for region: %s", region)
+// IsStale reports whether the pricing data is more than 30 days old
+func (p *EC2Pricing) IsStale() bool {
+    lastUpdated, err := time.Parse("2006-01-02", p.LastUpdated)
+    return err == nil && time.Since(lastUpdated) > 30*24*time.Hour
+}
+
+func sortedKeys[V any](m map[string]V) []string {
+    keys := make([]string, 0, len(m))
+    for key := range m {
+        keys = append(keys, key)
     }
+    sort.Strings(keys)
+    return keys
+}
 
-    instance, ok := regionPricing[instanceType]
+// IsRegionSupported checks if the static data has instance prices for a region
+func (p *EC2Pricing) IsRegionSupported(region string) bool {
+    _, ok := p.OnDemandInstances[region]
+    return ok
+}
+
+// GetInstancePrice returns the hourly price of an instance type. The static
+// data only has Linux prices.
+func (p *EC2Pricing) GetInstancePrice(ctx context.Context, instanceType, region string, platform Platform) (float64, error) {
+    if !platform.IsLinux() {
+        return 0, fmt.Errorf("pricing not available for platform %s in static pricing data", platform.Name)
+    }
+
+    instance, ok := p.OnDemandInstances[region][instanceType]
     if !ok {
-        return 0, "", fmt.Errorf("pricing not available for instance type: %s", instanceType)
+        return 0, fmt.Errorf("pricing not available for instance type %s in region %s", instanceType, region)
     }
+    return instance.PricePerHour, nil
+}
 
-    var savings float64
-    var recommendation string
+// GetVolumePrice returns the monthly price of one GB of a volume type
+func (p *EC2Pricing) GetVolumePrice(ctx context.Context, volumeType, region string) (float64, error) {
+    volume, err := p.volume(volumeType, region)
+    if err != nil {
+        return 0, err
+    }
+    return volume.PricePerGBMonth, nil
+}
 
-    // Check for T2 to T3 upgrade opportunity
-    if upgrade := instance.RecommendedUpgrade; upgrade != "" {
-        if upgradeInstance, ok := regionPricing[upgrade]; ok {
-            hourlyDiff := instance.PricePerHour - upgradeInstance.PricePerHour
-            savings = hourlyDiff * float64(hoursRunning)
-            recommendation = fmt.Sprintf("Upgrade to %s to save $%.2f per month", upgrade, savings*730) // 730 hours in a month
-        }
+// GetVolumeIOPSPrice returns the monthly price of one provisioned IOPS of a
+// volume type
+func (p *EC2Pricing) GetVolumeIOPSPrice(ctx context.Context, volumeType, region string) (float64, error) {
+    volume, err := p.volume(volumeType, region)
+    if err != nil {
+        return 0, err
     }
+    return volume.PricePerIOPSMonth, nil
+}
 
-    // Check for downsizing opportunity
-    if downgrade := instance.RecommendedDowngrade; downgrade != "" {
-        if downgradeInstance, ok := regionPricing[downgrade]; ok {
-            hourlyDiff := instance.PricePerHour - downgradeInstance.PricePerHour
-            downgradeSavings := hourlyDiff * float64(hoursRunning)
-            if downgradeSavings > savings {
-                savings = downgradeSavings
-                recommendation = fmt.Sprintf("Downgrade to %s to save $%.2f per month", downgrade, savings*730)
-            }
-        }
+// GetVolumeThroughputPrice returns the monthly price of one provisioned MiB/s
+// of a volume type
+func (p *EC2Pricing) GetVolumeThroughputPrice(ctx context.Context, volumeType, region string) (float64, error) {
+    volume, err := p.volume(volumeType, region)
+    if err != nil {
+        return 0, err
     }
+    return volume.PricePerMiBpsMonth, nil
+}
 
-    return savings, recommendation, nil
+// GetVolumeRates returns the prices of a volume type with its base price and
+// included IOPS and throughput
+func (p *EC2Pricing) GetVolumeRates(ctx context.Context, volumeType, region string) (Volume, error) {
+    return p.volume(volumeType, region)
 }
 
-// CalculateVolumeSavings calculates potential savings for an EBS volume
-func (p *EC2Pricing) CalculateVolumeSavings(region string, volumeType string, sizeGB int, iops int) (float64, string, error) {
-    regionPricing, ok := p.EBSVolumes[region]
+// GetSnapshotPrice returns the monthly price of one GB of snapshot storage
+func (p *EC2Pricing) GetSnapshotPrice(ctx context.Context, region string) (float64, error) {
+    snapshot, ok := p.EBSSnapshots[region]
     if !ok {
-        return 0, "", fmt.Errorf("pricing not available for region: %s", region)
+        return 0, fmt.Errorf("snapshot pricing not available for region: %s", region)
     }
+    return snapshot.PricePerGBMonth, nil
+}
 
-    volume, ok := regionPricing[volumeType]
+// GetSnapshotArchivePrice returns the monthly price of one GB of archived
+// snapshot storage
+func (p *EC2Pricing) GetSnapshotArchivePrice(ctx context.Context, region string) (float64, error) {
+    snapshot, ok := p.EBSSnapshots[region]
+    if !ok || snapshot.ArchivePricePerGBMonth == 0 {
+        return 0, fmt.Errorf("snapshot archive pricing not available for region: %s", region)
+    }
+    return snapshot.ArchivePricePerGBMonth, nil
+}
+
+// InstanceCatalog returns the catalog of the instance types of a region
+func (p *EC2Pricing) InstanceCatalog(ctx context.Context, region string) (*InstanceCatalog, error) {
+    instances, ok := p.OnDemandInstances[region]
     if !ok {
-        return 0, "", fmt.Errorf("pricing not available for volume type: %s", volumeType)
+        return nil, fmt.Errorf("pricing not available for region: %s", region)
+    }
+
+    catalog := &InstanceCatalog{region: region, specs: make(map[string]InstanceSpec)}
+    for instanceType, attrs := range instanceAttributes(instances) {
+        catalog.specs[instanceType] = newInstanceSpec(attrs, instances[instanceType].PricePerHour)
     }
+    return catalog, nil
+}
 
-    var monthlySavings float64
-    var recommendation string
+// GetCommitmentPrice always fails, as the static data has no commitment prices
+func (p *EC2Pricing) GetCommitmentPrice(ctx context.Context, instanceType, region string, platform Platform, terms CommitmentTerms) (CommitmentPrice, error) {
+    return CommitmentPrice{}, fmt.Errorf("%s pricing not available in static pricing data", terms.Kind)
+}
 
-    // Check for GP2 to GP3 migration opportunity
-    if volumeType == "gp2" && sizeGB >= p.SavingsOpportunities.VolumeOptimization.GP2ToGP3.MinimumSizeGB {
-        gp3Pricing := regionPricing["gp3"]
-        savingsPerGB := volume.PricePerGBMonth - gp3Pricing.PricePerGBMonth
-        monthlySavings = float64(sizeGB) * savingsPerGB
-        recommendation = fmt.Sprintf("Migrate to gp3 volume type to save $%.2f per month", monthlySavings)
+func (p *EC2Pricing) volume(volumeType, region string) (Volume, error) {
+    regionPricing, ok := p.EBSVolumes[region]
+    if !ok {
+        return Volume{}, fmt.Errorf("pricing not available for region: %s", region)
     }
 
-    // Check for over-provisioned IOPS
-    if (volumeType == "io1" || volumeType == "io2") && iops < p.SavingsOpportunities.VolumeOptimization.Underutilized.IOPSThreshold {
-        iopsPrice := volume.PricePerIOPSMonth * float64(iops)
-        recommendation = fmt.Sprintf("Consider reducing provisioned IOPS to save up to $%.2f per month", iopsPrice)
-        monthlySavings = iopsPrice
+    volume, ok := regionPricing[volumeType]
+    if !ok {
+        return Volume{}, fmt.Errorf("pricing not available for volume type: %s", volumeType)
     }
+    return volume, nil
+}
 
-    return monthlySavings, recommendation, nil
+// instanceAttributes describes the instances of a region as offer file
+// attributes. Types with a recommended upgrade are of a previous generation.
+func instanceAttributes(instances map[string]Instance) map[string]ProductAttributes {
+    attributes := make(map[string]ProductAttributes, len(instances))
+    for instanceType, instance := range instances {
+        currentGeneration := "Yes"
+        if instance.RecommendedUpgrade != "" {
+            currentGeneration = "No"
+        }
+        attributes[instanceType] = ProductAttributes{
+            InstanceType:          instanceType,
+            CurrentGeneration:     currentGeneration,
+            VCpu:                  strconv.Itoa(instance.VCPU),
+            Memory:                fmt.Sprintf("%g GiB", instance.MemoryGiB),
+            ProcessorArchitecture: "64-bit",
+            Tenancy:               sharedTenancy,
+            OperatingSystem:       "Linux",
+            PreInstalledSw:        "NA",
+            LicenseModel:          PlatformLinux.LicenseModel,
+            Operation:             PlatformLinux.Operation,
+            CapacityStatus:        capacityUsed,
+        }
+    }
+    return attributes
 }
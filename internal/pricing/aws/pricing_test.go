@@ -0,0 +1,37 @@
+package aws
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestParsePricingBundled(t *testing.T) {
+	if _, err := ParsePricing(bundledPricing); err != nil {
+		t.Fatalf("bundled pricing data: %v", err)
+	}
+}
+
+func TestParsePricingDeprecatedVolumeThresholds(t *testing.T) {
+	// Override files written before the volumes were right-sized from
+	// their metrics set the static thresholds
+	old := strings.Replace(string(bundledPricing), `"underutilized": {`,
+		`"underutilized": {"iops_threshold": 1000, "size_threshold_gb": 100,`, 1)
+	if old == string(bundledPricing) {
+		t.Fatal("bundled pricing data has no underutilized volume thresholds")
+	}
+
+	pricing, err := ParsePricing([]byte(old))
+	if err != nil {
+		t.Fatalf("pricing data with deprecated thresholds: %v", err)
+	}
+	if underutilized := pricing.SavingsOpportunities.VolumeOptimization.Underutilized; underutilized.HeadroomPercent != 30 {
+		t.Errorf("headroom = %d%%, want the bundled 30%%", underutilized.HeadroomPercent)
+	}
+}
+
+func TestParsePricingRejectsUnknownFields(t *testing.T) {
+	data := strings.Replace(string(bundledPricing), `"underutilized": {`, `"underutilized": {"iops_limit": 1000,`, 1)
+	if _, err := ParsePricing([]byte(data)); err == nil {
+		t.Error("pricing data with an unknown field parsed")
+	}
+}
@@ -0,0 +1,77 @@
+package aws
+
+import (
+	"context"
+	"fmt"
+)
+
+// PriceProvider prices the resources the blades analyse. EC2PricingService
+// prices from the AWS offer files and EC2Pricing from the static pricing
+// data, so blades can run on either and be tested with fake prices.
+type PriceProvider interface {
+	// IsRegionSupported reports whether prices are available for region
+	IsRegionSupported(region string) bool
+	// GetInstancePrice returns the on-demand hourly price of an instance
+	// type running platform
+	GetInstancePrice(ctx context.Context, instanceType, region string, platform Platform) (float64, error)
+	// GetVolumePrice returns the monthly price of one GB of a volume type
+	GetVolumePrice(ctx context.Context, volumeType, region string) (float64, error)
+	// GetVolumeIOPSPrice returns the monthly price of one provisioned IOPS
+	// of a volume type, or zero if the type has no IOPS charge
+	GetVolumeIOPSPrice(ctx context.Context, volumeType, region string) (float64, error)
+	// GetVolumeThroughputPrice returns the monthly price of one provisioned
+	// MiB/s of a volume type, or zero if the type has no throughput charge
+	GetVolumeThroughputPrice(ctx context.Context, volumeType, region string) (float64, error)
+	// GetVolumeRates returns every price of a volume type with the IOPS and
+	// throughput included in its storage price, for GetVolumeCost
+	GetVolumeRates(ctx context.Context, volumeType, region string) (Volume, error)
+	// GetSnapshotPrice returns the monthly price of one GB of EBS snapshot
+	// storage
+	GetSnapshotPrice(ctx context.Context, region string) (float64, error)
+	// GetSnapshotArchivePrice returns the monthly price of one GB of EBS
+	// snapshot storage in the archive tier
+	GetSnapshotArchivePrice(ctx context.Context, region string) (float64, error)
+	// InstanceCatalog returns the specifications and Linux prices of the
+	// instance types of a region
+	InstanceCatalog(ctx context.Context, region string) (*InstanceCatalog, error)
+	// GetCommitmentPrice returns the price of an instance type under one
+	// Reserved Instance or Savings Plans offering
+	GetCommitmentPrice(ctx context.Context, instanceType, region string, platform Platform, terms CommitmentTerms) (CommitmentPrice, error)
+}
+
+var (
+	_ PriceProvider = (*EC2PricingService)(nil)
+	_ PriceProvider = (*EC2Pricing)(nil)
+)
+
+// CalculateInstanceSavings calculates the monthly savings of moving from one
+// instance type to another, pricing both types for the same platform
+func CalculateInstanceSavings(ctx context.Context, prices PriceProvider, currentType, targetType, region string, platform Platform) (float64, error) {
+	currentPrice, err := prices.GetInstancePrice(ctx, currentType, region, platform)
+	if err != nil {
+		return 0, fmt.Errorf("failed to get current instance price: %v", err)
+	}
+
+	targetPrice, err := prices.GetInstancePrice(ctx, targetType, region, platform)
+	if err != nil {
+		return 0, fmt.Errorf("failed to get target instance price: %v", err)
+	}
+
+	return (currentPrice - targetPrice) * 730, nil // Average hours in a month
+}
+
+// CalculateVolumeSavings calculates the monthly savings of reconfiguring a
+// volume, including any change of type, size, IOPS or throughput
+func CalculateVolumeSavings(ctx context.Context, prices PriceProvider, current, target VolumeSpec, region string) (float64, error) {
+	currentCost, err := GetVolumeCost(ctx, prices, current, region)
+	if err != nil {
+		return 0, fmt.Errorf("failed to get current volume cost: %v", err)
+	}
+
+	targetCost, err := GetVolumeCost(ctx, prices, target, region)
+	if err != nil {
+		return 0, fmt.Errorf("failed to get target volume cost: %v", err)
+	}
+
+	return currentCost.Total() - targetCost.Total(), nil
+}
@@ -0,0 +1,209 @@
+package aws
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+
+	"github.com/sirupsen/logrus"
+	"github.com/yourusername/cloudshaver/internal/pricing/client"
+)
+
+// SourceOptions selects where the pricing service reads price lists from
+type SourceOptions struct {
+	// Cache configures the disk cache of downloaded price lists
+	Cache client.CacheOptions
+	// Snapshot is a directory written by ExportSnapshot; when set, price
+	// lists are read from it and no requests are made
+	Snapshot string
+	// Offline prices from the bundled ec2_pricing.json when no snapshot is
+	// set. It covers fewer regions and types than the offer files and has
+	// no commitment prices.
+	Offline bool
+}
+
+// NewSource returns the price list source selected by opts
+func NewSource(opts SourceOptions) (client.Source, error) {
+	switch {
+	case opts.Snapshot != "":
+		return client.NewSnapshotSource(opts.Snapshot)
+	case opts.Offline:
+		pricing, err := LoadPricing()
+		if err != nil {
+			return nil, err
+		}
+		if pricing.IsStale() {
+			logrus.Warnf("Offline pricing data is more than 30 days old (last updated: %s)", pricing.LastUpdated)
+		}
+		return &bundledSource{pricing: pricing}, nil
+	default:
+		pricingClient, err := client.NewPricingClient(client.DefaultPricingRegion, opts.Cache)
+		if err != nil {
+			return nil, fmt.Errorf("failed to create pricing client: %w", err)
+		}
+		return pricingClient, nil
+	}
+}
+
+// ExportSnapshot copies the EC2 offer files and Savings Plans rate files of
+// regions from source into a snapshot directory that NewSource reads with
+// SourceOptions.Snapshot
+func ExportSnapshot(ctx context.Context, source client.Source, dir string, regions []string) error {
+	for _, region := range regions {
+		body, err := source.OpenServicePricing(ctx, EC2Service, region)
+		if err := exportFile(dir, EC2Service, region, body, err); err != nil {
+			return err
+		}
+
+		body, err = source.OpenSavingsPlanPricing(ctx, SavingsPlanService, region)
+		if err := exportFile(dir, SavingsPlanService, region, body, err); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// exportFile writes an opened price list to the snapshot, reporting openErr
+// if it could not be opened
+func exportFile(dir, service, region string, body io.ReadCloser, openErr error) error {
+	if openErr != nil {
+		return fmt.Errorf("failed to get %s pricing data for %s: %w", service, region, openErr)
+	}
+	defer body.Close()
+
+	if err := client.WriteSnapshotFile(dir, service, region, body); err != nil {
+		return fmt.Errorf("failed to export %s pricing data for %s: %w", service, region, err)
+	}
+	return nil
+}
+
+// bundledSource serves the bundled ec2_pricing.json as offer files, so the
+// pricing service decodes it like downloaded price lists
+type bundledSource struct {
+	pricing *EC2Pricing
+}
+
+// bundledOnDemandTerm is the on-demand term code of the offer files
+const bundledOnDemandTerm = "JRTCKXETXF"
+
+// ServiceRegions lists the regions of the bundled data
+func (s *bundledSource) ServiceRegions(ctx context.Context, service string) ([]string, error) {
+	if service != EC2Service {
+		return nil, fmt.Errorf("service %s not found in bundled pricing data", service)
+	}
+	return sortedKeys(s.pricing.OnDemandInstances), nil
+}
+
+// OpenServicePricing renders the instances, volumes and snapshots of a
+// region as an EC2 offer file
+func (s *bundledSource) OpenServicePricing(ctx context.Context, service, region string) (io.ReadCloser, error) {
+	offer := bundledOffer{
+		Version:  s.pricing.LastUpdated,
+		Products: make(map[string]bundledProduct),
+		Terms:    map[string]map[string]map[string]bundledTerm{"OnDemand": {}},
+	}
+
+	switch service {
+	case EC2Service:
+		instances, ok := s.pricing.OnDemandInstances[region]
+		if !ok {
+			return nil, fmt.Errorf("region %s not found in bundled pricing data", region)
+		}
+		for instanceType, attrs := range instanceAttributes(instances) {
+			attrs.Location = s.pricing.RegionMapping[region]
+			offer.add("instance/"+instanceType, "Compute Instance", attrs, "Hrs", instances[instanceType].PricePerHour)
+		}
+		for volumeType, volume := range s.pricing.EBSVolumes[region] {
+			if usageType, ok := volumeStorageUsage[volumeType]; ok {
+				offer.add("storage/"+volumeType, "Storage", ProductAttributes{
+					UsageType:     usageType,
+					VolumeAPIName: volumeType,
+				}, "GB-Mo", volume.PricePerGBMonth)
+			}
+			if usageType, ok := volumeIOPSUsage[volumeType]; ok && volume.PricePerIOPSMonth > 0 {
+				offer.add("iops/"+volumeType, "System Operation", ProductAttributes{
+					UsageType:     usageType,
+					VolumeAPIName: volumeType,
+				}, "IOPS-Mo", volume.PricePerIOPSMonth)
+			}
+			// Only tiers starting where the offer files have one can be
+			// rendered as SKUs
+			for _, tier := range volume.IOPSTiers {
+				for _, usage := range volumeIOPSTierUsage[volumeType] {
+					if usage.fromIOPS == tier.FromIOPS {
+						offer.add(fmt.Sprintf("iops/%s/%d", volumeType, tier.FromIOPS), "System Operation", ProductAttributes{
+							UsageType:     usage.usageType,
+							VolumeAPIName: volumeType,
+						}, "IOPS-Mo", tier.PricePerIOPSMonth)
+					}
+				}
+			}
+			if usageType, ok := volumeThroughputUsage[volumeType]; ok && volume.PricePerMiBpsMonth > 0 {
+				offer.add("throughput/"+volumeType, "Provisioned Throughput", ProductAttributes{
+					UsageType:     usageType,
+					VolumeAPIName: volumeType,
+				}, "MiBps-Mo", volume.PricePerMiBpsMonth)
+			}
+		}
+		if snapshot, ok := s.pricing.EBSSnapshots[region]; ok {
+			offer.add("snapshot", "Storage Snapshot", ProductAttributes{
+				UsageType: snapshotUsage,
+			}, "GB-Mo", snapshot.PricePerGBMonth)
+			if snapshot.ArchivePricePerGBMonth > 0 {
+				offer.add("snapshot/archive", "Storage Snapshot", ProductAttributes{
+					UsageType: snapshotArchiveUsage,
+				}, "GB-Mo", snapshot.ArchivePricePerGBMonth)
+			}
+		}
+	default:
+		return nil, fmt.Errorf("service %s not found in bundled pricing data", service)
+	}
+
+	data, err := json.Marshal(offer)
+	if err != nil {
+		return nil, err
+	}
+	return io.NopCloser(bytes.NewReader(data)), nil
+}
+
+// OpenSavingsPlanPricing returns an empty rate file, as the bundled data has
+// no Savings Plans rates
+func (s *bundledSource) OpenSavingsPlanPricing(ctx context.Context, service, region string) (io.ReadCloser, error) {
+	return io.NopCloser(strings.NewReader(`{"products":[],"terms":{"savingsPlan":[]}}`)), nil
+}
+
+// bundledOffer is the subset of the offer file format decodeOffer reads
+type bundledOffer struct {
+	Version  string                                       `json:"version"`
+	Products map[string]bundledProduct                    `json:"products"`
+	Terms    map[string]map[string]map[string]bundledTerm `json:"terms"`
+}
+
+type bundledProduct struct {
+	SKU           string            `json:"sku"`
+	ProductFamily string            `json:"productFamily"`
+	Attributes    ProductAttributes `json:"attributes"`
+}
+
+type bundledTerm struct {
+	PriceDimensions map[string]PriceDimension `json:"priceDimensions"`
+}
+
+// add adds a product with a single on-demand price
+func (o *bundledOffer) add(sku, productFamily string, attrs ProductAttributes, unit string, price float64) {
+	o.Products[sku] = bundledProduct{SKU: sku, ProductFamily: productFamily, Attributes: attrs}
+	o.Terms["OnDemand"][sku] = map[string]bundledTerm{
+		sku + "." + bundledOnDemandTerm: {
+			PriceDimensions: map[string]PriceDimension{
+				sku + "." + bundledOnDemandTerm + ".6YS6EN2CT7": {
+					Unit:         unit,
+					PricePerUnit: map[string]string{"USD": strconv.FormatFloat(price, 'f', -1, 64)},
+				},
+			},
+		},
+	}
+}
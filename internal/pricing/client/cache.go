@@ -0,0 +1,224 @@
+package client
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// DefaultCacheMaxSize bounds the disk cache when no size is configured. A
+// regional EC2 offer file is several hundred MB, so this holds a handful of
+// regions.
+const DefaultCacheMaxSize = 8 << 30
+
+// metaSuffix names the file holding the validators of a cached file
+const metaSuffix = ".meta"
+
+// CacheOptions configures the on-disk pricing cache
+type CacheOptions struct {
+	// Dir is the cache directory; it defaults to "cloudshaver/pricing" under
+	// the user cache directory
+	Dir string
+	// MaxSize is the size in bytes above which the least recently used files
+	// are evicted; DefaultCacheMaxSize is used when zero
+	MaxSize int64
+	// TTL is how long index files are trusted before they are revalidated;
+	// CacheExpiration is used when zero. Offer files are immutable per
+	// version and are only revalidated when their version has no
+	// identifier.
+	TTL time.Duration
+}
+
+// cacheEntry holds the validators of a cached file
+type cacheEntry struct {
+	URL          string    `json:"url"`
+	ETag         string    `json:"etag,omitempty"`
+	LastModified string    `json:"last_modified,omitempty"`
+	FetchedAt    time.Time `json:"fetched_at"`
+}
+
+// diskCache stores downloaded pricing files under a directory. Files are
+// written to a temporary file and renamed into place, so concurrent scans and
+// interrupted downloads never leave a partial file behind.
+type diskCache struct {
+	dir     string
+	maxSize int64
+	ttl     time.Duration
+	mu      sync.Mutex
+}
+
+func newDiskCache(opts CacheOptions) (*diskCache, error) {
+	dir := opts.Dir
+	if dir == "" {
+		base, err := os.UserCacheDir()
+		if err != nil {
+			base = os.TempDir()
+		}
+		dir = filepath.Join(base, "cloudshaver", "pricing")
+	}
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return nil, fmt.Errorf("failed to create pricing cache directory: %w", err)
+	}
+
+	maxSize := opts.MaxSize
+	if maxSize <= 0 {
+		maxSize = DefaultCacheMaxSize
+	}
+	ttl := opts.TTL
+	if ttl <= 0 {
+		ttl = CacheExpiration
+	}
+
+	return &diskCache{dir: dir, maxSize: maxSize, ttl: ttl}, nil
+}
+
+func (c *diskCache) path(key string) string {
+	return filepath.Join(c.dir, filepath.FromSlash(key))
+}
+
+// lookup returns the validators of a cached file, or nil when it is missing
+func (c *diskCache) lookup(key string) *cacheEntry {
+	data, err := os.ReadFile(c.path(key) + metaSuffix)
+	if err != nil {
+		return nil
+	}
+	var entry cacheEntry
+	if err := json.Unmarshal(data, &entry); err != nil {
+		return nil
+	}
+	if _, err := os.Stat(c.path(key)); err != nil {
+		return nil
+	}
+	return &entry
+}
+
+// fresh reports whether a cached file can be used without revalidation
+func (c *diskCache) fresh(entry *cacheEntry) bool {
+	return time.Since(entry.FetchedAt) < c.ttl
+}
+
+// open opens a cached file and marks it as recently used
+func (c *diskCache) open(key string) (*os.File, error) {
+	now := time.Now()
+	_ = os.Chtimes(c.path(key), now, now)
+	return os.Open(c.path(key))
+}
+
+// store writes body and its validators atomically, then evicts the least
+// recently used files if the cache has grown past its size limit
+func (c *diskCache) store(key string, entry cacheEntry, body io.Reader) error {
+	path := c.path(key)
+	if err := writeFileAtomic(path, body); err != nil {
+		return err
+	}
+	if err := c.storeEntry(key, entry); err != nil {
+		return err
+	}
+	return c.evict(path)
+}
+
+// storeEntry replaces the validators of a cached file, e.g. after a 304
+func (c *diskCache) storeEntry(key string, entry cacheEntry) error {
+	data, err := json.Marshal(entry)
+	if err != nil {
+		return err
+	}
+	return writeFileAtomic(c.path(key)+metaSuffix, strings.NewReader(string(data)))
+}
+
+// evict removes the least recently used files until the cache fits its size
+// limit, never removing keep
+func (c *diskCache) evict(keep string) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	type cachedFile struct {
+		path    string
+		size    int64
+		modTime time.Time
+	}
+	var files []cachedFile
+	var total int64
+	err := filepath.WalkDir(c.dir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil || d.IsDir() || strings.HasSuffix(path, metaSuffix) || strings.Contains(d.Name(), ".tmp-") {
+			return err
+		}
+		info, err := d.Info()
+		if err != nil {
+			return nil
+		}
+		files = append(files, cachedFile{path: path, size: info.Size(), modTime: info.ModTime()})
+		total += info.Size()
+		return nil
+	})
+	if err != nil {
+		return fmt.Errorf("failed to scan pricing cache: %w", err)
+	}
+
+	sort.Slice(files, func(i, j int) bool { return files[i].modTime.Before(files[j].modTime) })
+	for _, file := range files {
+		if total <= c.maxSize {
+			break
+		}
+		if file.path == keep {
+			continue
+		}
+		if err := os.Remove(file.path); err != nil && !errors.Is(err, fs.ErrNotExist) {
+			return fmt.Errorf("failed to evict %s from pricing cache: %w", file.path, err)
+		}
+		_ = os.Remove(file.path + metaSuffix)
+		total -= file.size
+	}
+	return nil
+}
+
+// clear removes every cached file
+func (c *diskCache) clear() error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	entries, err := os.ReadDir(c.dir)
+	if err != nil {
+		return err
+	}
+	for _, entry := range entries {
+		if err := os.RemoveAll(filepath.Join(c.dir, entry.Name())); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// writeFileAtomic writes r to a temporary file next to path and renames it
+// into place
+func writeFileAtomic(path string, r io.Reader) error {
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return fmt.Errorf("failed to create directory for %s: %w", path, err)
+	}
+
+	tmp, err := os.CreateTemp(filepath.Dir(path), filepath.Base(path)+".tmp-*")
+	if err != nil {
+		return fmt.Errorf("failed to create %s: %w", path, err)
+	}
+	defer os.Remove(tmp.Name())
+
+	if _, err := io.Copy(tmp, r); err != nil {
+		tmp.Close()
+		return fmt.Errorf("failed to write %s: %w", path, err)
+	}
+	if err := tmp.Close(); err != nil {
+		return fmt.Errorf("failed to write %s: %w", path, err)
+	}
+	if err := os.Rename(tmp.Name(), path); err != nil {
+		return fmt.Errorf("failed to move %s into place: %w", path, err)
+	}
+	return nil
+}
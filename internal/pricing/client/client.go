@@ -1,11 +1,14 @@
 package client
 
 import (
+    "context"
     "encoding/json"
     "fmt"
     "io"
     "net/http"
-    "sync"
+    "path"
+    "sort"
+    "strings"
     "time"
 )
 
@@ -16,17 +19,12 @@ const (
     CacheExpiration     = 24 * time.Hour
 )
 
-// PricingClient handles AWS pricing API interactions
+// PricingClient handles AWS pricing API interactions. Downloaded files are
+// kept in an on-disk cache, so repeated runs only download what has changed.
 type PricingClient struct {
     httpClient  *http.Client
     region      string
-    cache       map[string]map[string]*CachedResponse
-    cacheMutex  sync.RWMutex
-}
-
-type CachedResponse struct {
-    Data      []byte
-    Timestamp time.Time
+    cache       *diskCache
 }
 
 type ServiceIndex struct {
@@ -35,30 +33,47 @@ type ServiceIndex struct {
     PublicationDate time.Time `json:"publicationDate"`
     Offers          map[string]struct {
         CurrentVersion      string            `json:"currentVersion"`
+        CurrentVersionURL  string            `json:"currentVersionUrl"`
         CurrentRegionIndex string            `json:"currentRegionIndexUrl"`
-        Regions            map[string]string `json:"regions"`
+        CurrentSavingsPlanIndex string       `json:"currentSavingsPlanIndexUrl"`
     } `json:"offers"`
 }
 
-// NewPricingClient creates a new AWS pricing API client
-func NewPricingClient(region string) *PricingClient {
+// RegionIndex lists the current offer file of each region of a service
+type RegionIndex struct {
+    Regions map[string]struct {
+        RegionCode        string `json:"regionCode"`
+        CurrentVersionURL string `json:"currentVersionUrl"`
+    } `json:"regions"`
+}
+
+// NewPricingClient creates a new AWS pricing API client caching downloads as
+// configured by cache
+func NewPricingClient(region string, cache CacheOptions) (*PricingClient, error) {
     if region == "" {
         region = DefaultPricingRegion
     }
 
+    diskCache, err := newDiskCache(cache)
+    if err != nil {
+        return nil, err
+    }
+
     return &PricingClient{
         httpClient: &http.Client{
-            Timeout: 30 * time.Second,
+            // The timeout covers reading the body, and regional EC2 offer
+            // files are hundreds of MB
+            Timeout: 15 * time.Minute,
         },
         region: region,
-        cache:  make(map[string]map[string]*CachedResponse),
-    }
+        cache:  diskCache,
+    }, nil
 }
 
 // GetServiceIndex retrieves the main AWS pricing index
-func (c *PricingClient) GetServiceIndex() (*ServiceIndex, error) {
+func (c *PricingClient) GetServiceIndex(ctx context.Context) (*ServiceIndex, error) {
     url := fmt.Sprintf("%s/%s", c.getBaseURL(), IndexFile)
-    data, err := c.fetchWithCache(url, c.region, "")
+    data, err := c.fetchBytes(ctx, url, IndexFile, false)
     if err != nil {
         return nil, err
     }
@@ -71,81 +86,259 @@ func (c *PricingClient) GetServiceIndex() (*ServiceIndex, error) {
     return &index, nil
 }
 
-// GetServicePricing retrieves pricing data for a specific service
-func (c *PricingClient) GetServicePricing(service, region string) ([]byte, error) {
-    index, err := c.GetServiceIndex()
+// GetRegionIndex retrieves the region index of a service. It is cached under
+// the current version of the service, so it is only downloaded again once the
+// service index publishes a new version.
+func (c *PricingClient) GetRegionIndex(ctx context.Context, service string) (*RegionIndex, error) {
+    index, err := c.GetServiceIndex(ctx)
     if err != nil {
         return nil, err
     }
 
     offer, exists := index.Offers[service]
-    if !exists {
+    if !exists || offer.CurrentRegionIndex == "" {
         return nil, fmt.Errorf("service %s not found in pricing index", service)
     }
 
-    var pricingURL string
-    if region != "" {
-        regionURL, exists := offer.Regions[region]
+    key, immutable := service+"/region_index.json", false
+    if offer.CurrentVersion != "" {
+        key, immutable = path.Join(service, offer.CurrentVersion, "region_index.json"), true
+    }
+
+    data, err := c.fetchBytes(ctx, c.absoluteURL(offer.CurrentRegionIndex), key, immutable)
+    if err != nil {
+        return nil, err
+    }
+
+    var regionIndex RegionIndex
+    if err := json.Unmarshal(data, &regionIndex); err != nil {
+        return nil, fmt.Errorf("failed to parse region index of %s: %v", service, err)
+    }
+
+    return &regionIndex, nil
+}
+
+// ServiceRegions lists the regions a service publishes prices for
+func (c *PricingClient) ServiceRegions(ctx context.Context, service string) ([]string, error) {
+    regionIndex, err := c.GetRegionIndex(ctx, service)
+    if err != nil {
+        return nil, err
+    }
+
+    regions := make([]string, 0, len(regionIndex.Regions))
+    for region := range regionIndex.Regions {
+        regions = append(regions, region)
+    }
+    sort.Strings(regions)
+    return regions, nil
+}
+
+// GetServicePricing retrieves pricing data for a specific service
+func (c *PricingClient) GetServicePricing(ctx context.Context, service, region string) ([]byte, error) {
+    pricingURL, err := c.servicePricingURL(ctx, service, region)
+    if err != nil {
+        return nil, err
+    }
+
+    key, immutable := offerCacheKey(service, region, pricingURL)
+    return c.fetchBytes(ctx, pricingURL, key, immutable)
+}
+
+// OpenServicePricing opens the offer file of a service for callers that
+// decode it incrementally. The file is downloaded to the cache first, so only
+// a new offer version is downloaded again. The caller must close the returned
+// reader.
+func (c *PricingClient) OpenServicePricing(ctx context.Context, service, region string) (io.ReadCloser, error) {
+    pricingURL, err := c.servicePricingURL(ctx, service, region)
+    if err != nil {
+        return nil, err
+    }
+
+    key, immutable := offerCacheKey(service, region, pricingURL)
+    return c.fetch(ctx, pricingURL, key, immutable)
+}
+
+// OpenSavingsPlanPricing opens the Savings Plans rate file of a service in
+// a region. The caller must close the returned reader.
+func (c *PricingClient) OpenSavingsPlanPricing(ctx context.Context, service, region string) (io.ReadCloser, error) {
+    index, err := c.GetServiceIndex(ctx)
+    if err != nil {
+        return nil, err
+    }
+
+    offer, exists := index.Offers[service]
+    if !exists || offer.CurrentSavingsPlanIndex == "" {
+        return nil, fmt.Errorf("savings plan %s not found in pricing index", service)
+    }
+
+    key, immutable := service+"/region_index.json", false
+    if offer.CurrentVersion != "" {
+        key, immutable = path.Join(service, offer.CurrentVersion, "region_index.json"), true
+    }
+
+    data, err := c.fetchBytes(ctx, c.absoluteURL(offer.CurrentSavingsPlanIndex), key, immutable)
+    if err != nil {
+        return nil, err
+    }
+
+    var regionIndex struct {
+        Regions []struct {
+            RegionCode string `json:"regionCode"`
+            VersionURL string `json:"versionUrl"`
+        } `json:"regions"`
+    }
+    if err := json.Unmarshal(data, &regionIndex); err != nil {
+        return nil, fmt.Errorf("failed to parse savings plan region index: %v", err)
+    }
+
+    for _, entry := range regionIndex.Regions {
+        if entry.RegionCode == region {
+            versionURL := c.absoluteURL(entry.VersionURL)
+            key, immutable := offerCacheKey(service, region, versionURL)
+            return c.fetch(ctx, versionURL, key, immutable)
+        }
+    }
+
+    return nil, fmt.Errorf("region %s not found for savings plan %s", region, service)
+}
+
+// servicePricingURL resolves the absolute URL of a service offer file, or of
+// its region index when region is empty
+func (c *PricingClient) servicePricingURL(ctx context.Context, service, region string) (string, error) {
+    if region == "" {
+        index, err := c.GetServiceIndex(ctx)
+        if err != nil {
+            return "", err
+        }
+
+        offer, exists := index.Offers[service]
         if !exists {
-            return nil, fmt.Errorf("region %s not found for service %s", region, service)
+            return "", fmt.Errorf("service %s not found in pricing index", service)
         }
-        pricingURL = regionURL
-    } else {
-        pricingURL = offer.CurrentRegionIndex
+        return c.absoluteURL(offer.CurrentRegionIndex), nil
+    }
+
+    regionIndex, err := c.GetRegionIndex(ctx, service)
+    if err != nil {
+        return "", err
     }
 
-    // Convert relative URL to absolute
-    if pricingURL[0] == '/' {
-        pricingURL = c.getBaseURL() + pricingURL
+    entry, exists := regionIndex.Regions[region]
+    if !exists {
+        return "", fmt.Errorf("region %s not found for service %s", region, service)
     }
 
-    return c.fetchWithCache(pricingURL, region, service)
+    return c.absoluteURL(entry.CurrentVersionURL), nil
 }
 
-func (c *PricingClient) fetchWithCache(url, region, service string) ([]byte, error) {
-    c.cacheMutex.RLock()
-    if regionCache, ok := c.cache[region]; ok {
-        if cached, ok := regionCache[service]; ok {
-            if time.Since(cached.Timestamp) < CacheExpiration {
-                c.cacheMutex.RUnlock()
-                return cached.Data, nil
-            }
+// offerCacheKey returns the cache key of a regional offer file. Offer URLs
+// embed the offer version, e.g. /offers/v1.0/aws/AmazonEC2/20240101000000/
+// us-east-1/index.json, and a published version never changes, so versioned
+// files are reported as immutable and served from the cache without
+// revalidation.
+func offerCacheKey(service, region, url string) (string, bool) {
+    segments := strings.Split(url, "/")
+    for i, segment := range segments {
+        if segment == service && i+1 < len(segments) && segments[i+1] != "current" && segments[i+1] != region {
+            return path.Join(service, region, segments[i+1]+".json"), true
         }
     }
-    c.cacheMutex.RUnlock()
+    return path.Join(service, region, "current.json"), false
+}
+
+// absoluteURL converts a URL relative to the pricing host to an absolute one
+func (c *PricingClient) absoluteURL(url string) string {
+    if strings.HasPrefix(url, "/") {
+        return c.getHost() + url
+    }
+    return url
+}
 
-    resp, err := c.httpClient.Get(url)
+// fetchBytes reads a file through the cache
+func (c *PricingClient) fetchBytes(ctx context.Context, url, key string, immutable bool) ([]byte, error) {
+    body, err := c.fetch(ctx, url, key, immutable)
     if err != nil {
-        return nil, fmt.Errorf("failed to fetch pricing data: %w", err)
+        return nil, err
     }
-    defer resp.Body.Close()
+    defer body.Close()
 
-    data, err := io.ReadAll(resp.Body)
+    data, err := io.ReadAll(body)
     if err != nil {
-        return nil, fmt.Errorf("failed to read response body: %w", err)
+        return nil, fmt.Errorf("failed to read pricing cache file: %w", err)
     }
+    return data, nil
+}
 
-    c.cacheMutex.Lock()
-    defer c.cacheMutex.Unlock()
+// fetch opens a file through the cache. Immutable files and files fetched
+// within the cache TTL are served from disk. Other cached files are
+// revalidated with a conditional GET, and a stale copy is served if the
+// pricing endpoint cannot be reached.
+func (c *PricingClient) fetch(ctx context.Context, url, key string, immutable bool) (io.ReadCloser, error) {
+    entry := c.cache.lookup(key)
+    if entry != nil && entry.URL != url {
+        entry = nil
+    }
+    if entry != nil && (immutable || c.cache.fresh(entry)) {
+        return c.cache.open(key)
+    }
 
-    if _, ok := c.cache[region]; !ok {
-        c.cache[region] = make(map[string]*CachedResponse)
+    req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+    if err != nil {
+        return nil, fmt.Errorf("failed to create pricing request: %w", err)
     }
-    c.cache[region][service] = &CachedResponse{
-        Data:      data,
-        Timestamp: time.Now(),
+    if entry != nil {
+        if entry.ETag != "" {
+            req.Header.Set("If-None-Match", entry.ETag)
+        }
+        if entry.LastModified != "" {
+            req.Header.Set("If-Modified-Since", entry.LastModified)
+        }
     }
 
-    return data, nil
+    resp, err := c.httpClient.Do(req)
+    if err != nil {
+        if entry != nil && ctx.Err() == nil {
+            return c.cache.open(key)
+        }
+        return nil, fmt.Errorf("failed to fetch pricing data: %w", err)
+    }
+    defer resp.Body.Close()
+
+    switch {
+    case resp.StatusCode == http.StatusNotModified && entry != nil:
+        entry.FetchedAt = time.Now()
+        if err := c.cache.storeEntry(key, *entry); err != nil {
+            return nil, err
+        }
+    case resp.StatusCode == http.StatusOK:
+        fetched := cacheEntry{
+            URL:          url,
+            ETag:         resp.Header.Get("ETag"),
+            LastModified: resp.Header.Get("Last-Modified"),
+            FetchedAt:    time.Now(),
+        }
+        if err := c.cache.store(key, fetched, resp.Body); err != nil {
+            return nil, err
+        }
+    default:
+        return nil, fmt.Errorf("failed to fetch pricing data from %s: unexpected status %s", url, resp.Status)
+    }
+
+    return c.cache.open(key)
 }
 
 func (c *PricingClient) getBaseURL() string {
     return fmt.Sprintf(BaseURL, c.region)
 }
 
-// ClearCache clears the pricing data cache
-func (c *PricingClient) ClearCache() {
-    c.cacheMutex.Lock()
-    c.cache = make(map[string]map[string]*CachedResponse)
-    c.cacheMutex.Unlock()
+// getHost returns the scheme and host of the pricing endpoint, which the
+// URLs in the pricing index are relative to
+func (c *PricingClient) getHost() string {
+    base := c.getBaseURL()
+    return base[:strings.Index(base, "/offers/")]
+}
+
+// ClearCache removes every cached pricing file
+func (c *PricingClient) ClearCache() error {
+    return c.cache.clear()
 }
@@ -0,0 +1,144 @@
+package client
+
+import (
+	"compress/gzip"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// Source supplies the price lists the pricing service decodes. PricingClient
+// downloads them from the AWS bulk pricing endpoint, and SnapshotSource reads
+// them from a local snapshot, so scans can run without network access.
+type Source interface {
+	// ServiceRegions lists the regions a service publishes prices for
+	ServiceRegions(ctx context.Context, service string) ([]string, error)
+	// OpenServicePricing opens the offer file of a service in a region
+	OpenServicePricing(ctx context.Context, service, region string) (io.ReadCloser, error)
+	// OpenSavingsPlanPricing opens the Savings Plans rate file of a service
+	// in a region
+	OpenSavingsPlanPricing(ctx context.Context, service, region string) (io.ReadCloser, error)
+}
+
+var (
+	_ Source = (*PricingClient)(nil)
+	_ Source = (*SnapshotSource)(nil)
+)
+
+// snapshotExt is the extension of the gzip-compressed files of a snapshot
+const snapshotExt = ".json.gz"
+
+// SnapshotSource reads price lists from a directory holding one file per
+// service and region, <dir>/<service>/<region>.json.gz, as written by
+// WriteSnapshotFile. Uncompressed <region>.json files are read as well.
+type SnapshotSource struct {
+	dir string
+}
+
+// NewSnapshotSource returns a source reading the snapshot in dir
+func NewSnapshotSource(dir string) (*SnapshotSource, error) {
+	info, err := os.Stat(dir)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open pricing snapshot: %w", err)
+	}
+	if !info.IsDir() {
+		return nil, fmt.Errorf("pricing snapshot %s is not a directory", dir)
+	}
+	return &SnapshotSource{dir: dir}, nil
+}
+
+// ServiceRegions lists the regions of a service present in the snapshot
+func (s *SnapshotSource) ServiceRegions(ctx context.Context, service string) ([]string, error) {
+	entries, err := os.ReadDir(filepath.Join(s.dir, service))
+	if err != nil {
+		return nil, fmt.Errorf("service %s not found in pricing snapshot: %w", service, err)
+	}
+
+	seen := make(map[string]bool)
+	var regions []string
+	for _, entry := range entries {
+		name := entry.Name()
+		region, ok := strings.CutSuffix(name, snapshotExt)
+		if !ok {
+			region, ok = strings.CutSuffix(name, ".json")
+		}
+		if ok && !entry.IsDir() && !seen[region] {
+			seen[region] = true
+			regions = append(regions, region)
+		}
+	}
+	sort.Strings(regions)
+	return regions, nil
+}
+
+// OpenServicePricing opens the offer file of a service in a region
+func (s *SnapshotSource) OpenServicePricing(ctx context.Context, service, region string) (io.ReadCloser, error) {
+	return s.open(service, region)
+}
+
+// OpenSavingsPlanPricing opens the Savings Plans rate file of a service in a
+// region; rate files are stored like offer files, under the Savings Plans
+// service code
+func (s *SnapshotSource) OpenSavingsPlanPricing(ctx context.Context, service, region string) (io.ReadCloser, error) {
+	return s.open(service, region)
+}
+
+func (s *SnapshotSource) open(service, region string) (io.ReadCloser, error) {
+	base := filepath.Join(s.dir, service, region)
+
+	file, err := os.Open(base + snapshotExt)
+	if errors.Is(err, fs.ErrNotExist) {
+		file, err = os.Open(base + ".json")
+		if errors.Is(err, fs.ErrNotExist) {
+			return nil, fmt.Errorf("region %s not found for service %s in pricing snapshot", region, service)
+		}
+		if err != nil {
+			return nil, err
+		}
+		return file, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	gz, err := gzip.NewReader(file)
+	if err != nil {
+		file.Close()
+		return nil, fmt.Errorf("failed to read pricing snapshot %s: %w", file.Name(), err)
+	}
+	return &gzipFile{Reader: gz, file: file}, nil
+}
+
+// gzipFile closes both the decompressor and the file beneath it
+type gzipFile struct {
+	*gzip.Reader
+	file *os.File
+}
+
+func (f *gzipFile) Close() error {
+	return errors.Join(f.Reader.Close(), f.file.Close())
+}
+
+// WriteSnapshotFile compresses the price list read from r into the snapshot
+// in dir, where SnapshotSource finds it for service and region. The file is
+// written atomically, so an interrupted export leaves any previous copy intact.
+func WriteSnapshotFile(dir, service, region string, r io.Reader) error {
+	pr, pw := io.Pipe()
+	go func() {
+		gz := gzip.NewWriter(pw)
+		_, err := io.Copy(gz, r)
+		if err == nil {
+			err = gz.Close()
+		}
+		pw.CloseWithError(err)
+	}()
+	defer pr.Close()
+
+	return writeFileAtomic(filepath.Join(dir, service, region+snapshotExt), pr)
+}
@@ -0,0 +1,170 @@
+package registry
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	awsutil "github.com/yourusername/cloudshaver/internal/aws"
+	"github.com/yourusername/cloudshaver/internal/inventory"
+	awspricing "github.com/yourusername/cloudshaver/internal/pricing/aws"
+	pricingclient "github.com/yourusername/cloudshaver/internal/pricing/client"
+	"github.com/yourusername/cloudshaver/internal/types"
+)
+
+// Env carries everything a blade constructor needs to build a blade for one
+// provider, account and region
+type Env struct {
+	Provider types.CloudProvider
+	Region   string
+	// Account is the scanned account; it is zero when scanning with the
+	// ambient credentials
+	Account   awsutil.Account
+	AWSConfig aws.Config
+	// EC2Inventory is shared by every AWS blade scanning the same account
+	// and region, so resources are listed only once
+	EC2Inventory *inventory.EC2Inventory
+	// MetricsLookback is the window of CloudWatch metrics blades examine;
+	// zero selects each blade's default
+	MetricsLookback time.Duration
+	// SteadyStateAge is how long an instance must have been running to count
+	// as steady-state usage for commitments; zero selects the blade's default
+	SteadyStateAge time.Duration
+	// IdleAge is how long an instance must have been stopped, or a volume
+	// unattached, to be reported; zero selects the thresholds of the static
+	// pricing data
+	IdleAge time.Duration
+	// SnapshotRetention is the age beyond which snapshots are reported as
+	// expired; zero selects the blade's default
+	SnapshotRetention time.Duration
+	// PricingSource supplies price lists; nil downloads them with the
+	// default disk cache
+	PricingSource pricingclient.Source
+	// Pricing is shared by the blades of every account and region of a
+	// scan, so each offer file is decoded once per scan; nil makes each
+	// blade price from PricingSource on its own
+	Pricing awspricing.PriceProvider
+}
+
+// Constructor builds a blade for the given environment
+type Constructor func(ctx context.Context, env Env) (types.Blade, error)
+
+// Registration describes a blade that can be built by the factory
+type Registration struct {
+	// Name uniquely identifies the blade, e.g. "ec2-optimization"
+	Name        string
+	Description string
+	Provider    types.CloudProvider
+	Category    types.BladeCategory
+	// Services lists the cloud services the blade calls, e.g. "ec2"
+	Services []string
+	New      Constructor
+}
+
+var (
+	mu            sync.RWMutex
+	registrations = make(map[string]Registration)
+)
+
+// Register makes a blade available to the factory. It is meant to be called
+// from the init function of the package that implements the blade and panics
+// if the registration is incomplete or the name is already taken.
+func Register(registration Registration) {
+	if registration.Name == "" {
+		panic("registry: blade registered without a name")
+	}
+	if registration.New == nil {
+		panic(fmt.Sprintf("registry: blade %s registered without a constructor", registration.Name))
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+
+	if _, exists := registrations[registration.Name]; exists {
+		panic(fmt.Sprintf("registry: blade %s registered twice", registration.Name))
+	}
+	registrations[registration.Name] = registration
+}
+
+// Lookup returns the registration of the named blade
+func Lookup(name string) (Registration, bool) {
+	mu.RLock()
+	defer mu.RUnlock()
+
+	registration, ok := registrations[name]
+	return registration, ok
+}
+
+// List returns all registered blades sorted by name
+func List() []Registration {
+	mu.RLock()
+	defer mu.RUnlock()
+
+	list := make([]Registration, 0, len(registrations))
+	for _, registration := range registrations {
+		list = append(list, registration)
+	}
+	sort.Slice(list, func(i, j int) bool {
+		return list[i].Name < list[j].Name
+	})
+	return list
+}
+
+// Select returns the blades registered for provider that match the include
+// list (all blades when empty) and none of the exclude list. Entries of both
+// lists are blade names or blade categories. Unknown entries are an error so
+// that typos do not silently disable blades.
+func Select(provider types.CloudProvider, include, exclude []string) ([]Registration, error) {
+	all := List()
+	if err := validateSelectors(all, include); err != nil {
+		return nil, err
+	}
+	if err := validateSelectors(all, exclude); err != nil {
+		return nil, err
+	}
+
+	var selected []Registration
+	for _, registration := range all {
+		if registration.Provider != provider {
+			continue
+		}
+		if len(include) > 0 && !matchesAny(registration, include) {
+			continue
+		}
+		if matchesAny(registration, exclude) {
+			continue
+		}
+		selected = append(selected, registration)
+	}
+	return selected, nil
+}
+
+func matchesAny(registration Registration, selectors []string) bool {
+	for _, selector := range selectors {
+		if strings.EqualFold(selector, registration.Name) ||
+			strings.EqualFold(selector, string(registration.Category)) {
+			return true
+		}
+	}
+	return false
+}
+
+func validateSelectors(all []Registration, selectors []string) error {
+	for _, selector := range selectors {
+		known := types.IsBladeCategory(selector)
+		for _, registration := range all {
+			if matchesAny(registration, []string{selector}) {
+				known = true
+				break
+			}
+		}
+		if !known {
+			return fmt.Errorf("unknown blade or category: %s", selector)
+		}
+	}
+	return nil
+}
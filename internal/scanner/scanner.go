@@ -0,0 +1,263 @@
+package scanner
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"sync"
+
+	"github.com/sirupsen/logrus"
+	awsutil "github.com/yourusername/cloudshaver/internal/aws"
+	"github.com/yourusername/cloudshaver/internal/factory"
+	"github.com/yourusername/cloudshaver/internal/types"
+)
+
+// DefaultConcurrency is the number of regions scanned in parallel when no
+// concurrency is configured
+const DefaultConcurrency = 4
+
+// Options controls how a scan is executed
+type Options struct {
+	// Concurrency bounds the number of account and region pairs scanned in
+	// parallel
+	Concurrency int
+}
+
+// Report is the merged outcome of a scan across accounts and regions
+type Report struct {
+	Accounts []awsutil.Account `json:"accounts,omitempty"`
+	Regions  []string          `json:"regions"`
+	Results  []BladeReport     `json:"results"`
+	Failures []Failure         `json:"failures,omitempty"`
+}
+
+// BladeReport holds the result of one blade merged across all scanned
+// accounts and regions
+type BladeReport struct {
+	Blade  string             `json:"blade"`
+	Result *types.BladeResult `json:"result"`
+}
+
+// Failure records a blade, region or account that failed without aborting
+// the scan
+type Failure struct {
+	AccountID string `json:"account_id,omitempty"`
+	Region    string `json:"region,omitempty"`
+	Blade     string `json:"blade,omitempty"`
+	Error     string `json:"error"`
+}
+
+// target is one account and region pair of a scan
+type target struct {
+	account awsutil.Account
+	region  string
+}
+
+// targetOutcome is what a single account and region contributes to the report
+type targetOutcome struct {
+	target   target
+	results  []BladeReport
+	failures []Failure
+}
+
+// Run creates and executes the configured blades in every account and region
+// of the scan. Targets are scanned with bounded concurrency and a failing
+// account or region does not stop the others. Unless the configuration sets
+// one, a single price provider is created for the whole scan. The returned
+// error is non-nil when accounts or pricing could not be loaded or when any
+// target failed; in the latter case the report is returned as well.
+func Run(ctx context.Context, bladeConfig factory.BladeConfig, opts Options) (*Report, error) {
+	accounts, err := factory.ResolveAccounts(ctx, bladeConfig)
+	if err != nil {
+		return nil, fmt.Errorf("failed to resolve accounts: %w", err)
+	}
+
+	if bladeConfig.Pricing == nil {
+		bladeConfig.Pricing, err = factory.NewPricing(ctx, bladeConfig)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	concurrency := opts.Concurrency
+	if concurrency <= 0 {
+		concurrency = DefaultConcurrency
+	}
+
+	var targets []target
+	var failures []Failure
+	for _, account := range accounts {
+		accountConfig := bladeConfig
+		accountConfig.Account = account
+
+		regions, err := factory.ResolveRegions(ctx, accountConfig)
+		if err != nil {
+			logrus.WithError(err).WithField("account", account.ID).Error("Failed to resolve regions")
+			failures = append(failures, Failure{
+				AccountID: account.ID,
+				Error:     fmt.Sprintf("failed to resolve regions: %v", err),
+			})
+			continue
+		}
+		for _, region := range regions {
+			targets = append(targets, target{account: account, region: region})
+		}
+	}
+
+	outcomes := make([]targetOutcome, len(targets))
+	sem := make(chan struct{}, concurrency)
+	var wg sync.WaitGroup
+
+	for i, t := range targets {
+		wg.Add(1)
+		go func(i int, t target) {
+			defer wg.Done()
+
+			select {
+			case sem <- struct{}{}:
+				defer func() { <-sem }()
+			case <-ctx.Done():
+				outcomes[i] = targetOutcome{
+					target:   t,
+					failures: []Failure{{AccountID: t.account.ID, Region: t.region, Error: ctx.Err().Error()}},
+				}
+				return
+			}
+
+			targetConfig := bladeConfig
+			targetConfig.Account = t.account
+			targetConfig.Region = t.region
+			outcomes[i] = scanTarget(ctx, targetConfig)
+		}(i, t)
+	}
+	wg.Wait()
+
+	report := mergeOutcomes(accounts, outcomes)
+	report.Failures = append(failures, report.Failures...)
+	if len(report.Failures) > 0 {
+		return report, fmt.Errorf("%d scan failures across %d accounts and %d regions",
+			len(report.Failures), len(accounts), len(report.Regions))
+	}
+	return report, nil
+}
+
+func scanTarget(ctx context.Context, bladeConfig factory.BladeConfig) targetOutcome {
+	t := target{account: bladeConfig.Account, region: bladeConfig.Region}
+	outcome := targetOutcome{target: t}
+	log := logrus.WithField("region", t.region)
+	if t.account.ID != "" {
+		log = log.WithField("account", t.account.ID)
+	}
+
+	blades, err := factory.CreateBlades(ctx, bladeConfig)
+	if err != nil {
+		log.WithError(err).Error("Failed to create blades")
+		outcome.failures = append(outcome.failures, Failure{
+			AccountID: t.account.ID,
+			Region:    t.region,
+			Error:     err.Error(),
+		})
+	}
+
+	for _, blade := range blades {
+		log.WithField("blade", blade.GetName()).Info("Running blade")
+
+		result, err := blade.Execute(ctx)
+		if err != nil {
+			log.WithError(err).WithField("blade", blade.GetName()).Error("Blade execution failed")
+			outcome.failures = append(outcome.failures, Failure{
+				AccountID: t.account.ID,
+				Region:    t.region,
+				Blade:     blade.GetName(),
+				Error:     err.Error(),
+			})
+		}
+		if result != nil {
+			outcome.results = append(outcome.results, BladeReport{Blade: blade.GetName(), Result: result})
+		}
+	}
+
+	return outcome
+}
+
+func mergeOutcomes(accounts []awsutil.Account, outcomes []targetOutcome) *Report {
+	report := &Report{}
+	for _, account := range accounts {
+		if account.ID != "" {
+			report.Accounts = append(report.Accounts, account)
+		}
+	}
+
+	merged := make(map[string]*types.BladeResult)
+	var order []string
+	regions := make(map[string]bool)
+
+	for _, outcome := range outcomes {
+		if !regions[outcome.target.region] {
+			regions[outcome.target.region] = true
+			report.Regions = append(report.Regions, outcome.target.region)
+		}
+		report.Failures = append(report.Failures, outcome.failures...)
+
+		for _, bladeReport := range outcome.results {
+			result, ok := merged[bladeReport.Blade]
+			if !ok {
+				result = &types.BladeResult{
+					CloudProvider:   bladeReport.Result.CloudProvider,
+					Category:        bladeReport.Result.Category,
+					ResourceType:    bladeReport.Result.ResourceType,
+					Recommendations: []string{},
+					Details:         make(map[string]string),
+					Findings:        []types.Finding{},
+				}
+				merged[bladeReport.Blade] = result
+				order = append(order, bladeReport.Blade)
+			}
+			mergeResult(result, bladeReport.Result, outcome.target)
+		}
+	}
+
+	sort.Strings(report.Regions)
+	sort.Strings(order)
+	for _, blade := range order {
+		result := merged[blade]
+		types.SortFindingsBySavings(result.Findings)
+		report.Results = append(report.Results, BladeReport{Blade: blade, Result: result})
+	}
+
+	return report
+}
+
+// mergeResult folds the result of one account and region into the merged
+// result of its blade, tagging everything that is not already tagged
+func mergeResult(merged, result *types.BladeResult, t target) {
+	merged.PotentialSavings += result.PotentialSavings
+	merged.MonthlyCost += result.MonthlyCost
+	if result.Timestamp.After(merged.Timestamp) {
+		merged.Timestamp = result.Timestamp
+	}
+
+	prefix := t.region
+	if t.account.ID != "" {
+		prefix = t.account.ID + "/" + t.region
+	}
+
+	for _, recommendation := range result.Recommendations {
+		merged.Recommendations = append(merged.Recommendations, fmt.Sprintf("[%s] %s", prefix, recommendation))
+	}
+	for key, value := range result.Details {
+		merged.Details[prefix+"/"+key] = value
+	}
+	for _, finding := range result.Findings {
+		if finding.Region == "" {
+			finding.Region = t.region
+		}
+		if finding.AccountID == "" {
+			finding.AccountID = t.account.ID
+		}
+		if finding.AccountAlias == "" && finding.AccountID == t.account.ID {
+			finding.AccountAlias = t.account.Alias
+		}
+		merged.Findings = append(merged.Findings, finding)
+	}
+}
@@ -1,6 +1,9 @@
 package types
 
-import "time"
+import (
+	"context"
+	"time"
+)
 
 // BladeResult represents the output of a cost-saving blade
 type BladeResult struct {
@@ -11,6 +14,7 @@ type BladeResult struct {
 	PotentialSavings float64           `json:"potential_savings"`
 	Recommendations  []string          `json:"recommendations"`
 	Details          map[string]string `json:"details"`
+	Findings         []Finding         `json:"findings"`
 
 	Timestamp   time.Time `json:"timestamp"`
 	MonthlyCost float64   `json:"monthly_cost,omitempty"`
@@ -18,8 +22,9 @@ type BladeResult struct {
 
 // Blade interface defines the contract for cost-saving blades
 type Blade interface {
-	// Execute runs the cost-saving analysis
-	Execute() (*BladeResult, error)
+	// Execute runs the cost-saving analysis. When ctx is cancelled it returns
+	// the partial result gathered so far together with a non-nil error.
+	Execute(ctx context.Context) (*BladeResult, error)
 
 	// GetName returns the name of the blade
 	GetName() string
@@ -41,14 +46,25 @@ const (
 type BladeCategory string
 
 const (
-	ComputeOptimization   BladeCategory = "compute"
-	StorageOptimization   BladeCategory = "storage"
-	NetworkOptimization   BladeCategory = "network"
-	DatabaseOptimization  BladeCategory = "database"
-	ContainerOptimization BladeCategory = "container"
-	BladeUnattachedVolume BladeCategory = "unattached_volume"
+	ComputeOptimization    BladeCategory = "compute"
+	StorageOptimization    BladeCategory = "storage"
+	NetworkOptimization    BladeCategory = "network"
+	DatabaseOptimization   BladeCategory = "database"
+	ContainerOptimization  BladeCategory = "container"
+	CommitmentOptimization BladeCategory = "commitment"
+	BladeUnattachedVolume  BladeCategory = "unattached_volume"
 )
 
+// IsBladeCategory reports whether name is one of the standard blade categories
+func IsBladeCategory(name string) bool {
+	switch BladeCategory(name) {
+	case ComputeOptimization, StorageOptimization, NetworkOptimization,
+		DatabaseOptimization, ContainerOptimization, CommitmentOptimization, BladeUnattachedVolume:
+		return true
+	}
+	return false
+}
+
 // VolumeState represents the state of an EBS volume
 type VolumeState string
 
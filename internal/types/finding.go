@@ -0,0 +1,102 @@
+package types
+
+import "sort"
+
+// FindingKind identifies the kind of cost-saving opportunity a finding reports
+type FindingKind string
+
+const (
+	FindingGenerationUpgrade     FindingKind = "generation-upgrade"
+	FindingRightsize             FindingKind = "rightsize"
+	FindingStoppedInstance       FindingKind = "stopped-instance"
+	FindingUnattachedVolume      FindingKind = "unattached-volume"
+	FindingVolumeMigration       FindingKind = "volume-migration"
+	FindingOverprovisionedVolume FindingKind = "overprovisioned-volume"
+	FindingOrphanedSnapshot      FindingKind = "orphaned-snapshot"
+	FindingExpiredSnapshot       FindingKind = "expired-snapshot"
+	FindingRedundantSnapshots    FindingKind = "redundant-snapshots"
+	FindingSnapshotArchive       FindingKind = "snapshot-archive"
+	FindingSpot                  FindingKind = "spot"
+	FindingCommitmentPurchase    FindingKind = "commitment-purchase"
+	FindingIdleCommitment        FindingKind = "idle-commitment"
+	FindingExpiringCommitment    FindingKind = "expiring-commitment"
+)
+
+// Confidence expresses how certain a blade is that a finding's savings are achievable
+type Confidence string
+
+const (
+	ConfidenceHigh   Confidence = "high"
+	ConfidenceMedium Confidence = "medium"
+	ConfidenceLow    Confidence = "low"
+)
+
+// Finding is a single cost-saving opportunity for one cloud resource.
+// All cost figures are monthly and in USD.
+type Finding struct {
+	ResourceID     string            `json:"resource_id"`
+	ResourceARN    string            `json:"resource_arn,omitempty"`
+	ResourceType   string            `json:"resource_type"`
+	Region         string            `json:"region"`
+	AccountID      string            `json:"account_id,omitempty"`
+	AccountAlias   string            `json:"account_alias,omitempty"`
+	Kind           FindingKind       `json:"kind"`
+	Recommendation string            `json:"recommendation"`
+	CurrentCost    float64           `json:"current_cost"`
+	ProjectedCost  float64           `json:"projected_cost"`
+	Savings        float64           `json:"savings"`
+	Confidence     Confidence        `json:"confidence"`
+	Details        map[string]string `json:"details,omitempty"`
+}
+
+// Key identifies the resource and kind of a finding, so the same opportunity
+// reported twice can be recognised
+func (f Finding) Key() string {
+	return f.AccountID + "/" + f.Region + "/" + string(f.Kind) + "/" + f.ResourceID
+}
+
+// AddFinding appends a finding to the result and adds its savings to the total
+func (r *BladeResult) AddFinding(finding Finding) {
+	r.Findings = append(r.Findings, finding)
+	r.PotentialSavings += finding.Savings
+}
+
+// SortFindingsBySavings orders findings by descending savings, breaking ties by key
+func SortFindingsBySavings(findings []Finding) {
+	sort.SliceStable(findings, func(i, j int) bool {
+		if findings[i].Savings != findings[j].Savings {
+			return findings[i].Savings > findings[j].Savings
+		}
+		return findings[i].Key() < findings[j].Key()
+	})
+}
+
+// FilterFindings returns the findings for which keep returns true
+func FilterFindings(findings []Finding, keep func(Finding) bool) []Finding {
+	var filtered []Finding
+	for _, finding := range findings {
+		if keep(finding) {
+			filtered = append(filtered, finding)
+		}
+	}
+	return filtered
+}
+
+// DedupeFindings drops findings whose key was already seen, keeping the one
+// with the highest savings
+func DedupeFindings(findings []Finding) []Finding {
+	index := make(map[string]int, len(findings))
+	var deduped []Finding
+	for _, finding := range findings {
+		key := finding.Key()
+		if i, ok := index[key]; ok {
+			if finding.Savings > deduped[i].Savings {
+				deduped[i] = finding
+			}
+			continue
+		}
+		index[key] = len(deduped)
+		deduped = append(deduped, finding)
+	}
+	return deduped
+}